@@ -8,8 +8,11 @@ import (
 )
 
 type CreateBudget struct {
-	CategoryID types.ID `json:"cid"`
-	Amount     float64  `json:"amount"`
+	CategoryID types.ID            `json:"cid"`
+	Amount     types.Money         `json:"amount"`
+	Period     models.BudgetPeriod `json:"period"`
+	Anchor     *types.Timestamp    `json:"anchor,omitempty"`
+	Thresholds []int               `json:"thresholds"`
 }
 
 type CreateCategory struct {
@@ -19,11 +22,41 @@ type CreateCategory struct {
 	Color string              `json:"color"`
 }
 
+type CreateRecurrence struct {
+	CategoryID types.ID         `json:"cid"`
+	WalletID   *types.ID        `json:"wid,omitempty"`
+	Title      string           `json:"title"`
+	Amount     types.Money      `json:"amount"`
+	Currency   *string          `json:"currency,omitempty"`
+	Frequency  models.Frequency `json:"frequency"`
+	Interval   int              `json:"interval"`
+	Start      types.Timestamp  `json:"start"`
+	Until      *types.Timestamp `json:"until,omitempty"`
+	Count      *int             `json:"count,omitempty"`
+}
+
 type CreateTransaction struct {
-	CategoryID types.ID        `json:"cid"`
+	CategoryID *types.ID       `json:"cid,omitempty"`
+	WalletID   *types.ID       `json:"wid,omitempty"`
 	Title      string          `json:"title"`
-	Amount     float64         `json:"amount"`
+	Amount     types.Money     `json:"amount"`
+	Currency   *string         `json:"currency,omitempty"`
 	Timestamp  types.Timestamp `json:"timestamp"`
+	Splits     []SplitLine     `json:"splits,omitempty"`
+}
+
+type CreateTransfer struct {
+	FromWalletID types.ID        `json:"from"`
+	ToWalletID   types.ID        `json:"to"`
+	Title        string          `json:"title"`
+	Amount       types.Money     `json:"amount"`
+	Currency     *string         `json:"currency,omitempty"`
+	Timestamp    types.Timestamp `json:"timestamp"`
+}
+
+type CreateWallet struct {
+	Name           string      `json:"name"`
+	OpeningBalance types.Money `json:"openingBalance"`
 }
 
 type Mutation struct {
@@ -31,3 +64,43 @@ type Mutation struct {
 
 type Query struct {
 }
+
+type SetBudget struct {
+	CategoryID types.ID            `json:"cid"`
+	Amount     types.Money         `json:"amount"`
+	Period     models.BudgetPeriod `json:"period"`
+	Anchor     *types.Timestamp    `json:"anchor,omitempty"`
+	Thresholds []int               `json:"thresholds"`
+}
+
+type SplitLine struct {
+	CategoryID types.ID    `json:"cid"`
+	Amount     types.Money `json:"amount"`
+}
+
+type Subscription struct {
+}
+
+type UpdateCategory struct {
+	Name  *string `json:"name,omitempty"`
+	Emoji *string `json:"emoji,omitempty"`
+	Color *string `json:"color,omitempty"`
+}
+
+type UpdateGroup struct {
+	Currency *string `json:"currency,omitempty"`
+}
+
+type UpdateTransaction struct {
+	CategoryID *types.ID        `json:"cid,omitempty"`
+	WalletID   *types.ID        `json:"wid,omitempty"`
+	Title      *string          `json:"title,omitempty"`
+	Amount     *types.Money     `json:"amount,omitempty"`
+	Currency   *string          `json:"currency,omitempty"`
+	Timestamp  *types.Timestamp `json:"timestamp,omitempty"`
+}
+
+type UpdateWallet struct {
+	Name           *string      `json:"name,omitempty"`
+	OpeningBalance *types.Money `json:"openingBalance,omitempty"`
+}
@@ -2,7 +2,16 @@
 
 package graphql
 
-import "finawise.app/server/repository"
+import (
+	"context"
+
+	"finawise.app/server/models"
+	"finawise.app/server/models/types"
+	"finawise.app/server/repository"
+	"finawise.app/server/services/account"
+	"finawise.app/server/services/alerts"
+	"finawise.app/server/services/pubsub"
+)
 
 // This file will not be regenerated automatically.
 //
@@ -10,4 +19,60 @@ import "finawise.app/server/repository"
 
 type Resolver struct {
 	Repository repository.Repository
+	Accounts   *account.Service
+	Alerts     *alerts.Service
+	Broker     *pubsub.Broker
+}
+
+// transactionCreated alerts the budget thresholds reached by a transaction
+// created, and publishes it to the devices of the group.
+func (r *Resolver) transactionCreated(gid int64, txn models.Transaction, splits []models.Split) {
+	r.Alerts.Transaction(gid, txn, splits)
+	r.Broker.Transactions.Publish(gid, models.TransactionChange{Action: models.ChangeActionCreated, ID: txn.ID, Transaction: &txn})
+}
+
+// walletID returns wid if given, or else the ID of the default wallet of the
+// group.
+func (r *Resolver) walletID(gid int64, wid *types.ID) (types.ID, error) {
+	if wid != nil {
+		return *wid, nil
+	}
+	w, err := r.Repository.GetWallet(gid, types.ZeroID)
+	return w.ID, err
+}
+
+// toSplits converts the split lines of an input into splits.
+func toSplits(lines []SplitLine) []models.Split {
+	splits := make([]models.Split, len(lines))
+	for i, l := range lines {
+		splits[i] = models.Split{CategoryID: l.CategoryID, Amount: l.Amount}
+	}
+	return splits
+}
+
+// subscribe relays the messages of topic to the group of the session, for as
+// long as the account is still a member of it. Members removed or gone to
+// another group keep their websocket open, so membership is checked again
+// before every message.
+func subscribe[T any](ctx context.Context, r *Resolver, topic *pubsub.Topic[T]) <-chan T {
+	session := ctx.Value("session").(account.Session)
+	ctx, cancel := context.WithCancel(ctx)
+	msgs := topic.Subscribe(ctx, session.GroupID)
+	ch := make(chan T)
+	go func() {
+		defer close(ch)
+		defer cancel()
+		for msg := range msgs {
+			a, err := r.Repository.GetAccount(session.AccountID)
+			if err != nil || a.GroupID != session.GroupID {
+				return
+			}
+			select {
+			case ch <- msg:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch
 }
@@ -6,6 +6,8 @@ package graphql
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	"finawise.app/server/models"
 	"finawise.app/server/models/types"
@@ -14,18 +16,90 @@ import (
 )
 
 // Summary is the resolver for the summary field.
-func (r *accountResolver) Summary(ctx context.Context, obj *models.Account) (models.AccountSummary, error) {
-	return r.Repository.GetAccountSummary(obj.ID)
+func (r *accountResolver) Summary(ctx context.Context, obj *models.Account, from *types.Timestamp, to *types.Timestamp) (models.AccountSummary, error) {
+	return r.Repository.GetAccountSummary(obj.ID, from, to)
+}
+
+// ByCategory is the resolver for the byCategory field.
+func (r *accountSummaryResolver) ByCategory(ctx context.Context, obj *models.AccountSummary) ([]models.CategorySummary, error) {
+	return r.Repository.GetCategorySummaries(obj.AccountID, obj.From, obj.To)
+}
+
+// ByTag is the resolver for the byTag field.
+func (r *accountSummaryResolver) ByTag(ctx context.Context, obj *models.AccountSummary) ([]models.TagSummary, error) {
+	return r.Repository.GetTagSummaries(obj.AccountID, obj.From, obj.To)
+}
+
+// Previous is the resolver for the previous field.
+func (r *accountSummaryResolver) Previous(ctx context.Context, obj *models.AccountSummary) (*models.AccountSummary, error) {
+	from, to, ok := obj.PreviousRange()
+	if !ok {
+		return nil, nil
+	}
+	as, err := r.Repository.GetAccountSummary(obj.AccountID, &from, &to)
+	return &as, err
+}
+
+// Thresholds is the resolver for the thresholds field.
+func (r *budgetResolver) Thresholds(ctx context.Context, obj *models.Budget) ([]int, error) {
+	return obj.Thresholds, nil
+}
+
+// Spent is the resolver for the spent field.
+func (r *budgetResolver) Spent(ctx context.Context, obj *models.Budget) (types.Money, error) {
+	session := ctx.Value("session").(account.Session)
+	s, err := r.Alerts.Spending(session.GroupID, *obj, time.Now())
+	return s.Spent, err
+}
+
+// Remaining is the resolver for the remaining field.
+func (r *budgetResolver) Remaining(ctx context.Context, obj *models.Budget) (types.Money, error) {
+	session := ctx.Value("session").(account.Session)
+	s, err := r.Alerts.Spending(session.GroupID, *obj, time.Now())
+	return s.Remaining(), err
+}
+
+// PeriodStart is the resolver for the periodStart field.
+func (r *budgetResolver) PeriodStart(ctx context.Context, obj *models.Budget) (types.Timestamp, error) {
+	start, _ := obj.Period.Bounds(obj.Anchor.Time, time.Now())
+	return types.Timestamp{Time: start}, nil
+}
+
+// PeriodEnd is the resolver for the periodEnd field.
+func (r *budgetResolver) PeriodEnd(ctx context.Context, obj *models.Budget) (types.Timestamp, error) {
+	_, end := obj.Period.Bounds(obj.Anchor.Time, time.Now())
+	return types.Timestamp{Time: end}, nil
+}
+
+// History is the resolver for the history field.
+func (r *budgetResolver) History(ctx context.Context, obj *models.Budget, last int) ([]models.BudgetSpending, error) {
+	if last < 0 || last > 120 {
+		return nil, fmt.Errorf("last must be between 0 and 120")
+	}
+	session := ctx.Value("session").(account.Session)
+	history := make([]models.BudgetSpending, 0, last)
+	start, _ := obj.Period.Bounds(obj.Anchor.Time, time.Now())
+	for range last {
+		s, err := r.Alerts.Spending(session.GroupID, *obj, start.Add(-time.Second))
+		if err != nil {
+			return nil, err
+		}
+		history = append(history, s)
+		start = s.PeriodStart.Time
+	}
+	return history, nil
 }
 
 // Category is the resolver for the category field.
 func (r *budgetResolver) Category(ctx context.Context, obj *models.Budget) (models.Category, error) {
-	return r.Repository.GetCategory(obj.CategoryID)
+	session := ctx.Value("session").(account.Session)
+	return r.Repository.GetCategory(session.GroupID, obj.CategoryID)
 }
 
 // Budget is the resolver for the budget field.
 func (r *categoryResolver) Budget(ctx context.Context, obj *models.Category) (*models.Budget, error) {
-	b, err := r.Repository.GetBudget(obj.ID)
+	session := ctx.Value("session").(account.Session)
+	b, err := r.Repository.GetBudget(session.GroupID, obj.ID)
 	if err == repository.ErrNoRows {
 		return nil, nil
 	}
@@ -33,9 +107,30 @@ func (r *categoryResolver) Budget(ctx context.Context, obj *models.Category) (*m
 }
 
 // Transactions is the resolver for the transactions field.
-func (r *categoryResolver) Transactions(ctx context.Context, obj *models.Category) ([]models.Transaction, error) {
+func (r *categoryResolver) Transactions(ctx context.Context, obj *models.Category, filter *models.TransactionFilter, first *int, after *types.Cursor, last *int, before *types.Cursor) (models.TransactionConnection, error) {
+	session := ctx.Value("session").(account.Session)
+	var f models.TransactionFilter
+	if filter != nil {
+		f = *filter
+	}
+	page := models.Page{First: first, After: after, Last: last, Before: before}
+	return r.Repository.ListTransactions(session.AccountID, &obj.ID, f, page)
+}
+
+// Category is the resolver for the category field.
+func (r *categorySummaryResolver) Category(ctx context.Context, obj *models.CategorySummary) (models.Category, error) {
 	session := ctx.Value("session").(account.Session)
-	return r.Repository.ListTransactions(session.AccountID, &obj.ID, nil)
+	return r.Repository.GetCategory(session.GroupID, obj.CategoryID)
+}
+
+// Members is the resolver for the members field.
+func (r *groupResolver) Members(ctx context.Context, obj *models.Group) ([]models.Account, error) {
+	return r.Repository.ListMembers(obj.ID)
+}
+
+// Invitations is the resolver for the invitations field.
+func (r *groupResolver) Invitations(ctx context.Context, obj *models.Group) ([]models.Invitation, error) {
+	return r.Repository.ListInvitations(obj.ID, types.Timestamp{Time: time.Now()})
 }
 
 // CreateCategory is the resolver for the createCategory field.
@@ -53,6 +148,56 @@ func (r *mutationResolver) CreateCategory(ctx context.Context, c CreateCategory)
 		return
 	}
 	cat.ID = id
+	r.Broker.Categories.Publish(session.GroupID, models.CategoryChange{Action: models.ChangeActionCreated, ID: cat.ID, Category: &cat})
+	return
+}
+
+// UpdateCategory is the resolver for the updateCategory field.
+func (r *mutationResolver) UpdateCategory(ctx context.Context, id types.ID, c UpdateCategory) (cat models.Category, err error) {
+	session := ctx.Value("session").(account.Session)
+	cat, err = r.Repository.GetCategory(session.GroupID, id)
+	if err != nil {
+		return
+	}
+	if c.Name != nil {
+		cat.Name = *c.Name
+	}
+	if c.Emoji != nil {
+		cat.Emoji = *c.Emoji
+	}
+	if c.Color != nil {
+		cat.Color = *c.Color
+	}
+	if err = r.Repository.UpdateCategory(cat); err != nil {
+		return
+	}
+	r.Broker.Categories.Publish(session.GroupID, models.CategoryChange{Action: models.ChangeActionUpdated, ID: cat.ID, Category: &cat})
+	return
+}
+
+// DeleteCategory is the resolver for the deleteCategory field.
+func (r *mutationResolver) DeleteCategory(ctx context.Context, id types.ID, reassignTo *types.ID) (types.ID, error) {
+	session := ctx.Value("session").(account.Session)
+	if err := r.Repository.DeleteCategory(session.GroupID, id, reassignTo); err != nil {
+		return id, err
+	}
+	r.Broker.Categories.Publish(session.GroupID, models.CategoryChange{Action: models.ChangeActionDeleted, ID: id})
+	return id, nil
+}
+
+// MergeCategories is the resolver for the mergeCategories field.
+func (r *mutationResolver) MergeCategories(ctx context.Context, sources []types.ID, target types.ID) (cat models.Category, err error) {
+	session := ctx.Value("session").(account.Session)
+	if err = r.Repository.MergeCategories(session.GroupID, sources, target); err != nil {
+		return
+	}
+	for _, id := range sources {
+		r.Broker.Categories.Publish(session.GroupID, models.CategoryChange{Action: models.ChangeActionDeleted, ID: id})
+	}
+	if cat, err = r.Repository.GetCategory(session.GroupID, target); err != nil {
+		return
+	}
+	r.Broker.Categories.Publish(session.GroupID, models.CategoryChange{Action: models.ChangeActionUpdated, ID: cat.ID, Category: &cat})
 	return
 }
 
@@ -60,39 +205,405 @@ func (r *mutationResolver) CreateCategory(ctx context.Context, c CreateCategory)
 func (r *mutationResolver) CreateTransaction(ctx context.Context, t CreateTransaction) (txn models.Transaction, err error) {
 	session := ctx.Value("session").(account.Session)
 	txn = models.Transaction{
-		CategoryID: t.CategoryID,
-		AccountID:  session.AccountID,
-		Title:      t.Title,
-		Amount:     t.Amount,
-		Timestamp:  t.Timestamp,
+		AccountID: session.AccountID,
+		Title:     t.Title,
+		Amount:    t.Amount,
+		Timestamp: t.Timestamp,
+	}
+	if t.CategoryID != nil {
+		txn.CategoryID = *t.CategoryID
+	}
+	if txn.WalletID, err = r.walletID(session.GroupID, t.WalletID); err != nil {
+		return
 	}
-	id, err := r.Repository.CreateTransaction(txn)
+	if t.Currency != nil {
+		txn.Currency = *t.Currency
+	} else {
+		// default to the base currency of the group
+		g, err := r.Repository.GetGroup(session.GroupID)
+		if err != nil {
+			return txn, err
+		}
+		txn.Currency = g.Currency
+	}
+	splits := toSplits(t.Splits)
+	id, err := r.Repository.CreateTransaction(session.GroupID, txn, splits)
 	if err != nil {
 		return
 	}
+	if len(splits) > 0 {
+		// the category follows the splits
+		if txn, err = r.Repository.GetTransaction(session.AccountID, id); err != nil {
+			return
+		}
+	}
 	txn.ID = id
+	r.transactionCreated(session.GroupID, txn, splits)
 	return
 }
 
+// UpdateTransaction is the resolver for the updateTransaction field.
+func (r *mutationResolver) UpdateTransaction(ctx context.Context, id types.ID, t UpdateTransaction) (txn models.Transaction, err error) {
+	session := ctx.Value("session").(account.Session)
+	txn, err = r.Repository.GetTransaction(session.AccountID, id)
+	if err != nil {
+		return
+	}
+	if t.CategoryID != nil {
+		txn.CategoryID = *t.CategoryID
+	}
+	if t.WalletID != nil {
+		txn.WalletID = *t.WalletID
+	}
+	if t.Title != nil {
+		txn.Title = *t.Title
+	}
+	if t.Amount != nil {
+		txn.Amount = *t.Amount
+	}
+	if t.Currency != nil {
+		txn.Currency = *t.Currency
+	}
+	if t.Timestamp != nil {
+		txn.Timestamp = *t.Timestamp
+	}
+	if err = r.Repository.UpdateTransaction(session.GroupID, txn); err != nil {
+		return
+	}
+	r.Broker.Transactions.Publish(session.GroupID, models.TransactionChange{Action: models.ChangeActionUpdated, ID: txn.ID, Transaction: &txn})
+	return
+}
+
+// DeleteTransaction is the resolver for the deleteTransaction field.
+func (r *mutationResolver) DeleteTransaction(ctx context.Context, id types.ID) (types.ID, error) {
+	session := ctx.Value("session").(account.Session)
+	if err := r.Repository.DeleteTransaction(session.AccountID, id); err != nil {
+		return id, err
+	}
+	r.Broker.Transactions.Publish(session.GroupID, models.TransactionChange{Action: models.ChangeActionDeleted, ID: id})
+	return id, nil
+}
+
+// SplitTransaction is the resolver for the splitTransaction field.
+func (r *mutationResolver) SplitTransaction(ctx context.Context, id types.ID, splits []SplitLine) (models.Transaction, error) {
+	session := ctx.Value("session").(account.Session)
+	txn, err := r.Repository.GetTransaction(session.AccountID, id)
+	if err != nil {
+		return txn, err
+	}
+	lines := toSplits(splits)
+	if txn, err = r.Repository.SetSplits(session.GroupID, txn, lines); err != nil {
+		return txn, err
+	}
+	r.Alerts.Transaction(session.GroupID, txn, lines)
+	r.Broker.Transactions.Publish(session.GroupID, models.TransactionChange{Action: models.ChangeActionUpdated, ID: txn.ID, Transaction: &txn})
+	return txn, nil
+}
+
 // CreateBudget is the resolver for the createBudget field.
 func (r *mutationResolver) CreateBudget(ctx context.Context, b CreateBudget) (bud models.Budget, err error) {
+	session := ctx.Value("session").(account.Session)
 	bud = models.Budget{
 		CategoryID: b.CategoryID,
 		Amount:     b.Amount,
+		Period:     b.Period,
+		Anchor:     b.Period.DefaultAnchor(),
+		Thresholds: b.Thresholds,
+	}
+	if b.Anchor != nil {
+		bud.Anchor = *b.Anchor
+	}
+	if err = r.Repository.CreateBudget(session.GroupID, bud); err != nil {
+		return
+	}
+	r.Broker.Budgets.Publish(session.GroupID, models.BudgetChange{Action: models.ChangeActionCreated, CategoryID: bud.CategoryID, Budget: &bud})
+	return
+}
+
+// SetBudget is the resolver for the setBudget field.
+func (r *mutationResolver) SetBudget(ctx context.Context, b SetBudget) (bud models.Budget, err error) {
+	session := ctx.Value("session").(account.Session)
+	bud = models.Budget{
+		CategoryID: b.CategoryID,
+		Amount:     b.Amount,
+		Period:     b.Period,
+		Anchor:     b.Period.DefaultAnchor(),
+		Thresholds: b.Thresholds,
+	}
+	if b.Anchor != nil {
+		bud.Anchor = *b.Anchor
+	}
+	if err = r.Repository.SetBudget(session.GroupID, bud); err != nil {
+		return
+	}
+	r.Broker.Budgets.Publish(session.GroupID, models.BudgetChange{Action: models.ChangeActionUpdated, CategoryID: bud.CategoryID, Budget: &bud})
+	return
+}
+
+// RemoveBudget is the resolver for the removeBudget field.
+func (r *mutationResolver) RemoveBudget(ctx context.Context, cid types.ID) (types.ID, error) {
+	session := ctx.Value("session").(account.Session)
+	if err := r.Repository.RemoveBudget(session.GroupID, cid); err != nil {
+		return cid, err
+	}
+	r.Broker.Budgets.Publish(session.GroupID, models.BudgetChange{Action: models.ChangeActionDeleted, CategoryID: cid})
+	return cid, nil
+}
+
+// CreateRecurrence is the resolver for the createRecurrence field.
+func (r *mutationResolver) CreateRecurrence(ctx context.Context, rec CreateRecurrence) (recurrence models.Recurrence, err error) {
+	session := ctx.Value("session").(account.Session)
+	recurrence = models.Recurrence{
+		AccountID:  session.AccountID,
+		CategoryID: rec.CategoryID,
+		Title:      rec.Title,
+		Amount:     rec.Amount,
+		Frequency:  rec.Frequency,
+		Interval:   rec.Interval,
+		Start:      rec.Start,
+		Until:      rec.Until,
+		Count:      rec.Count,
+	}
+	if recurrence.WalletID, err = r.walletID(session.GroupID, rec.WalletID); err != nil {
+		return
+	}
+	if rec.Currency != nil {
+		recurrence.Currency = *rec.Currency
+	} else {
+		// default to the base currency of the group
+		g, err := r.Repository.GetGroup(session.GroupID)
+		if err != nil {
+			return recurrence, err
+		}
+		recurrence.Currency = g.Currency
+	}
+	recurrence.Schedule()
+	id, err := r.Repository.CreateRecurrence(session.GroupID, recurrence)
+	if err != nil {
+		return
+	}
+	recurrence.ID = id
+	// catch up on the occurrences before now, instead of waiting for the
+	// scheduler, which creates those beyond models.MaxDue
+	if txns := recurrence.Due(time.Now()); len(txns) > 0 {
+		if txns, err = r.Repository.CreateOccurrences(recurrence, txns); err != nil {
+			return
+		}
+		for _, txn := range txns {
+			r.transactionCreated(session.GroupID, txn, nil)
+		}
+	}
+	return
+}
+
+// DeleteRecurrence is the resolver for the deleteRecurrence field.
+func (r *mutationResolver) DeleteRecurrence(ctx context.Context, id types.ID) (types.ID, error) {
+	session := ctx.Value("session").(account.Session)
+	return id, r.Repository.DeleteRecurrence(session.AccountID, id)
+}
+
+// MarkNotificationsRead is the resolver for the markNotificationsRead field.
+func (r *mutationResolver) MarkNotificationsRead(ctx context.Context, ids []types.ID) (int, error) {
+	session := ctx.Value("session").(account.Session)
+	return r.Repository.MarkNotificationsRead(session.GroupID, ids)
+}
+
+// CreateWallet is the resolver for the createWallet field.
+func (r *mutationResolver) CreateWallet(ctx context.Context, w CreateWallet) (models.Wallet, error) {
+	session := ctx.Value("session").(account.Session)
+	wallet := models.Wallet{
+		GroupID:        session.GroupID,
+		Name:           w.Name,
+		OpeningBalance: w.OpeningBalance,
+	}
+	id, err := r.Repository.CreateWallet(wallet)
+	wallet.ID = id
+	return wallet, err
+}
+
+// UpdateWallet is the resolver for the updateWallet field.
+func (r *mutationResolver) UpdateWallet(ctx context.Context, id types.ID, w UpdateWallet) (models.Wallet, error) {
+	session := ctx.Value("session").(account.Session)
+	wallet, err := r.Repository.GetWallet(session.GroupID, id)
+	if err != nil {
+		return wallet, err
+	}
+	if w.Name != nil {
+		wallet.Name = *w.Name
+	}
+	if w.OpeningBalance != nil {
+		wallet.OpeningBalance = *w.OpeningBalance
+	}
+	return wallet, r.Repository.UpdateWallet(wallet)
+}
+
+// DeleteWallet is the resolver for the deleteWallet field.
+func (r *mutationResolver) DeleteWallet(ctx context.Context, id types.ID, reassignTo *types.ID) (types.ID, error) {
+	session := ctx.Value("session").(account.Session)
+	return id, r.Repository.DeleteWallet(session.GroupID, id, reassignTo)
+}
+
+// CreateTransfer is the resolver for the createTransfer field.
+func (r *mutationResolver) CreateTransfer(ctx context.Context, t CreateTransfer) (models.Transfer, error) {
+	session := ctx.Value("session").(account.Session)
+	transfer := models.Transfer{
+		AccountID:    session.AccountID,
+		FromWalletID: t.FromWalletID,
+		ToWalletID:   t.ToWalletID,
+		Amount:       t.Amount,
+		Title:        t.Title,
+		Timestamp:    t.Timestamp,
+	}
+	if t.Currency != nil {
+		transfer.Currency = *t.Currency
+	} else {
+		// default to the base currency of the group
+		g, err := r.Repository.GetGroup(session.GroupID)
+		if err != nil {
+			return transfer, err
+		}
+		transfer.Currency = g.Currency
+	}
+	id, err := r.Repository.CreateTransfer(session.GroupID, transfer)
+	transfer.ID = id
+	return transfer, err
+}
+
+// DeleteTransfer is the resolver for the deleteTransfer field.
+func (r *mutationResolver) DeleteTransfer(ctx context.Context, id types.ID) (types.ID, error) {
+	session := ctx.Value("session").(account.Session)
+	return id, r.Repository.DeleteTransfer(session.AccountID, id)
+}
+
+// TagTransaction is the resolver for the tagTransaction field.
+func (r *mutationResolver) TagTransaction(ctx context.Context, id types.ID, tags []string) (models.Transaction, error) {
+	session := ctx.Value("session").(account.Session)
+	if err := r.Repository.TagTransaction(session.GroupID, session.AccountID, id, tags); err != nil {
+		return models.Transaction{}, err
 	}
-	err = r.Repository.CreateBudget(bud)
+	txn, err := r.Repository.GetTransaction(session.AccountID, id)
+	if err != nil {
+		return txn, err
+	}
+	r.Broker.Transactions.Publish(session.GroupID, models.TransactionChange{Action: models.ChangeActionUpdated, ID: txn.ID, Transaction: &txn})
+	return txn, nil
+}
+
+// UntagTransaction is the resolver for the untagTransaction field.
+func (r *mutationResolver) UntagTransaction(ctx context.Context, id types.ID, tags []string) (models.Transaction, error) {
+	session := ctx.Value("session").(account.Session)
+	if err := r.Repository.UntagTransaction(session.GroupID, session.AccountID, id, tags); err != nil {
+		return models.Transaction{}, err
+	}
+	txn, err := r.Repository.GetTransaction(session.AccountID, id)
+	if err != nil {
+		return txn, err
+	}
+	r.Broker.Transactions.Publish(session.GroupID, models.TransactionChange{Action: models.ChangeActionUpdated, ID: txn.ID, Transaction: &txn})
+	return txn, nil
+}
+
+// DeleteTag is the resolver for the deleteTag field.
+func (r *mutationResolver) DeleteTag(ctx context.Context, id types.ID) (types.ID, error) {
+	session := ctx.Value("session").(account.Session)
+	return id, r.Repository.DeleteTag(session.GroupID, id)
+}
+
+// UpdateGroup is the resolver for the updateGroup field.
+func (r *mutationResolver) UpdateGroup(ctx context.Context, g UpdateGroup) (grp models.Group, err error) {
+	session := ctx.Value("session").(account.Session)
+	grp, err = r.Repository.GetGroup(session.GroupID)
+	if err != nil {
+		return
+	}
+	if g.Currency != nil {
+		grp.Currency = *g.Currency
+	}
+	err = r.Repository.UpdateGroup(grp)
 	return
 }
 
+// DeleteAccount is the resolver for the deleteAccount field.
+func (r *mutationResolver) DeleteAccount(ctx context.Context, password string) (int64, error) {
+	session := ctx.Value("session").(account.Session)
+	return session.AccountID, r.Accounts.Delete(session.AccountID, password)
+}
+
+// CreateInvitation is the resolver for the createInvitation field.
+func (r *mutationResolver) CreateInvitation(ctx context.Context, role models.Role) (models.Invitation, error) {
+	session := ctx.Value("session").(account.Session)
+	return r.Accounts.Invite(session, role)
+}
+
+// DeleteInvitation is the resolver for the deleteInvitation field.
+func (r *mutationResolver) DeleteInvitation(ctx context.Context, code string) (string, error) {
+	session := ctx.Value("session").(account.Session)
+	return code, r.Repository.DeleteInvitation(session.GroupID, code)
+}
+
+// AcceptInvitation is the resolver for the acceptInvitation field.
+func (r *mutationResolver) AcceptInvitation(ctx context.Context, code string) (models.Group, error) {
+	session := ctx.Value("session").(account.Session)
+	gid, err := r.Accounts.Join(session.AccountID, code)
+	if err != nil {
+		return models.Group{}, err
+	}
+	return r.Repository.GetGroup(gid)
+}
+
+// LeaveGroup is the resolver for the leaveGroup field.
+func (r *mutationResolver) LeaveGroup(ctx context.Context) (models.Group, error) {
+	session := ctx.Value("session").(account.Session)
+	gid, err := r.Repository.LeaveGroup(session.AccountID)
+	if err != nil {
+		return models.Group{}, err
+	}
+	return r.Repository.GetGroup(gid)
+}
+
+// RemoveMember is the resolver for the removeMember field.
+func (r *mutationResolver) RemoveMember(ctx context.Context, id int64) (int64, error) {
+	session := ctx.Value("session").(account.Session)
+	if id == session.AccountID {
+		return 0, account.ErrSelf
+	}
+	return id, r.Repository.RemoveMember(session.GroupID, session.AccountID, id)
+}
+
+// SetRole is the resolver for the setRole field.
+func (r *mutationResolver) SetRole(ctx context.Context, id int64, role models.Role) (models.Account, error) {
+	session := ctx.Value("session").(account.Session)
+	if id == session.AccountID {
+		return models.Account{}, account.ErrSelf
+	}
+	if err := r.Repository.SetRole(session.GroupID, session.AccountID, id, role); err != nil {
+		return models.Account{}, err
+	}
+	return r.Repository.GetAccount(id)
+}
+
+// Category is the resolver for the category field.
+func (r *notificationResolver) Category(ctx context.Context, obj *models.Notification) (models.Category, error) {
+	session := ctx.Value("session").(account.Session)
+	return r.Repository.GetCategory(session.GroupID, obj.CategoryID)
+}
+
 // Account is the resolver for the account field.
 func (r *queryResolver) Account(ctx context.Context) (models.Account, error) {
 	session := ctx.Value("session").(account.Session)
 	return r.Repository.GetAccount(session.AccountID)
 }
 
+// Group is the resolver for the group field.
+func (r *queryResolver) Group(ctx context.Context) (models.Group, error) {
+	session := ctx.Value("session").(account.Session)
+	return r.Repository.GetGroup(session.GroupID)
+}
+
 // Category is the resolver for the category field.
 func (r *queryResolver) Category(ctx context.Context, id types.ID) (models.Category, error) {
-	return r.Repository.GetCategory(id)
+	session := ctx.Value("session").(account.Session)
+	return r.Repository.GetCategory(session.GroupID, id)
 }
 
 // Categories is the resolver for the categories field.
@@ -103,13 +614,22 @@ func (r *queryResolver) Categories(ctx context.Context, ct *models.CategoryType)
 
 // Transaction is the resolver for the transaction field.
 func (r *queryResolver) Transaction(ctx context.Context, id types.ID) (models.Transaction, error) {
-	return r.Repository.GetTransaction(id)
+	session := ctx.Value("session").(account.Session)
+	return r.Repository.GetTransaction(session.AccountID, id)
 }
 
 // Transactions is the resolver for the transactions field.
-func (r *queryResolver) Transactions(ctx context.Context, ct *models.CategoryType) ([]models.Transaction, error) {
+func (r *queryResolver) Transactions(ctx context.Context, ct *models.CategoryType, filter *models.TransactionFilter, first *int, after *types.Cursor, last *int, before *types.Cursor) (models.TransactionConnection, error) {
 	session := ctx.Value("session").(account.Session)
-	return r.Repository.ListTransactions(session.AccountID, nil, ct)
+	var f models.TransactionFilter
+	if filter != nil {
+		f = *filter
+	}
+	if ct != nil {
+		f.Type = ct
+	}
+	page := models.Page{First: first, After: after, Last: last, Before: before}
+	return r.Repository.ListTransactions(session.AccountID, nil, f, page)
 }
 
 // Budgets is the resolver for the budgets field.
@@ -118,32 +638,217 @@ func (r *queryResolver) Budgets(ctx context.Context) ([]models.Budget, error) {
 	return r.Repository.GetBudgets(session.GroupID)
 }
 
+// Recurrence is the resolver for the recurrence field.
+func (r *queryResolver) Recurrence(ctx context.Context, id types.ID) (models.Recurrence, error) {
+	session := ctx.Value("session").(account.Session)
+	return r.Repository.GetRecurrence(session.AccountID, id)
+}
+
+// Recurrences is the resolver for the recurrences field.
+func (r *queryResolver) Recurrences(ctx context.Context) ([]models.Recurrence, error) {
+	session := ctx.Value("session").(account.Session)
+	return r.Repository.ListRecurrences(session.AccountID)
+}
+
+// Notifications is the resolver for the notifications field.
+func (r *queryResolver) Notifications(ctx context.Context, unreadOnly bool) ([]models.Notification, error) {
+	session := ctx.Value("session").(account.Session)
+	return r.Repository.ListNotifications(session.GroupID, unreadOnly)
+}
+
+// Search is the resolver for the search field.
+func (r *queryResolver) Search(ctx context.Context, text string, first *int, after *types.Cursor) (models.SearchConnection, error) {
+	session := ctx.Value("session").(account.Session)
+	page := models.Page{First: first, After: after}
+	return r.Repository.SearchTransactions(session.AccountID, text, page)
+}
+
+// Timeseries is the resolver for the timeseries field.
+func (r *queryResolver) Timeseries(ctx context.Context, from types.Timestamp, to types.Timestamp, interval models.Interval, groupBy models.TimeSeriesGroup) ([]models.TimeSeries, error) {
+	session := ctx.Value("session").(account.Session)
+	return r.Repository.GetTimeSeries(session.AccountID, from, to, interval, groupBy)
+}
+
+// Wallets is the resolver for the wallets field.
+func (r *queryResolver) Wallets(ctx context.Context, asOf *types.Timestamp) ([]models.Wallet, error) {
+	session := ctx.Value("session").(account.Session)
+	ws, err := r.Repository.ListWallets(session.GroupID)
+	for i := range ws {
+		ws[i].AsOf = asOf
+	}
+	return ws, err
+}
+
+// Transfers is the resolver for the transfers field.
+func (r *queryResolver) Transfers(ctx context.Context, wid *types.ID) ([]models.Transfer, error) {
+	session := ctx.Value("session").(account.Session)
+	return r.Repository.ListTransfers(session.AccountID, wid)
+}
+
+// Tags is the resolver for the tags field.
+func (r *queryResolver) Tags(ctx context.Context) ([]models.Tag, error) {
+	session := ctx.Value("session").(account.Session)
+	return r.Repository.ListTags(session.GroupID)
+}
+
+// Category is the resolver for the category field.
+func (r *recurrenceResolver) Category(ctx context.Context, obj *models.Recurrence) (models.Category, error) {
+	session := ctx.Value("session").(account.Session)
+	return r.Repository.GetCategory(session.GroupID, obj.CategoryID)
+}
+
+// Wallet is the resolver for the wallet field.
+func (r *recurrenceResolver) Wallet(ctx context.Context, obj *models.Recurrence) (models.Wallet, error) {
+	session := ctx.Value("session").(account.Session)
+	return r.Repository.GetWallet(session.GroupID, obj.WalletID)
+}
+
+// Category is the resolver for the category field.
+func (r *splitResolver) Category(ctx context.Context, obj *models.Split) (models.Category, error) {
+	session := ctx.Value("session").(account.Session)
+	return r.Repository.GetCategory(session.GroupID, obj.CategoryID)
+}
+
+// TransactionChanged is the resolver for the transactionChanged field.
+func (r *subscriptionResolver) TransactionChanged(ctx context.Context) (<-chan models.TransactionChange, error) {
+	return subscribe(ctx, r.Resolver, &r.Broker.Transactions), nil
+}
+
+// BudgetChanged is the resolver for the budgetChanged field.
+func (r *subscriptionResolver) BudgetChanged(ctx context.Context) (<-chan models.BudgetChange, error) {
+	return subscribe(ctx, r.Resolver, &r.Broker.Budgets), nil
+}
+
+// CategoryChanged is the resolver for the categoryChanged field.
+func (r *subscriptionResolver) CategoryChanged(ctx context.Context) (<-chan models.CategoryChange, error) {
+	return subscribe(ctx, r.Resolver, &r.Broker.Categories), nil
+}
+
+// Tag is the resolver for the tag field.
+func (r *tagSummaryResolver) Tag(ctx context.Context, obj *models.TagSummary) (models.Tag, error) {
+	session := ctx.Value("session").(account.Session)
+	return r.Repository.GetTag(session.GroupID, obj.TagID)
+}
+
+// Category is the resolver for the category field.
+func (r *timeSeriesResolver) Category(ctx context.Context, obj *models.TimeSeries) (*models.Category, error) {
+	if obj.CategoryID == nil {
+		return nil, nil
+	}
+	session := ctx.Value("session").(account.Session)
+	c, err := r.Repository.GetCategory(session.GroupID, *obj.CategoryID)
+	return &c, err
+}
+
 // Category is the resolver for the category field.
 func (r *transactionResolver) Category(ctx context.Context, obj *models.Transaction) (models.Category, error) {
-	return r.Repository.GetCategory(obj.CategoryID)
+	session := ctx.Value("session").(account.Session)
+	return r.Repository.GetCategory(session.GroupID, obj.CategoryID)
+}
+
+// Splits is the resolver for the splits field.
+func (r *transactionResolver) Splits(ctx context.Context, obj *models.Transaction) ([]models.Split, error) {
+	return r.Repository.ListSplits(obj.AccountID, obj.ID)
+}
+
+// Wallet is the resolver for the wallet field.
+func (r *transactionResolver) Wallet(ctx context.Context, obj *models.Transaction) (models.Wallet, error) {
+	session := ctx.Value("session").(account.Session)
+	return r.Repository.GetWallet(session.GroupID, obj.WalletID)
+}
+
+// Tags is the resolver for the tags field.
+func (r *transactionResolver) Tags(ctx context.Context, obj *models.Transaction) ([]models.Tag, error) {
+	return r.Repository.ListTransactionTags(obj.AccountID, obj.ID)
+}
+
+// From is the resolver for the from field.
+func (r *transferResolver) From(ctx context.Context, obj *models.Transfer) (models.Wallet, error) {
+	session := ctx.Value("session").(account.Session)
+	return r.Repository.GetWallet(session.GroupID, obj.FromWalletID)
+}
+
+// To is the resolver for the to field.
+func (r *transferResolver) To(ctx context.Context, obj *models.Transfer) (models.Wallet, error) {
+	session := ctx.Value("session").(account.Session)
+	return r.Repository.GetWallet(session.GroupID, obj.ToWalletID)
+}
+
+// Balance is the resolver for the balance field.
+func (r *walletResolver) Balance(ctx context.Context, obj *models.Wallet) (types.Money, error) {
+	session := ctx.Value("session").(account.Session)
+	asOf := types.Timestamp{Time: time.Now()}
+	if obj.AsOf != nil {
+		asOf = *obj.AsOf
+	}
+	return r.Repository.GetBalance(session.GroupID, obj.ID, asOf)
 }
 
 // Account returns AccountResolver implementation.
 func (r *Resolver) Account() AccountResolver { return &accountResolver{r} }
 
+// AccountSummary returns AccountSummaryResolver implementation.
+func (r *Resolver) AccountSummary() AccountSummaryResolver { return &accountSummaryResolver{r} }
+
 // Budget returns BudgetResolver implementation.
 func (r *Resolver) Budget() BudgetResolver { return &budgetResolver{r} }
 
 // Category returns CategoryResolver implementation.
 func (r *Resolver) Category() CategoryResolver { return &categoryResolver{r} }
 
+// CategorySummary returns CategorySummaryResolver implementation.
+func (r *Resolver) CategorySummary() CategorySummaryResolver { return &categorySummaryResolver{r} }
+
+// Group returns GroupResolver implementation.
+func (r *Resolver) Group() GroupResolver { return &groupResolver{r} }
+
 // Mutation returns MutationResolver implementation.
 func (r *Resolver) Mutation() MutationResolver { return &mutationResolver{r} }
 
+// Notification returns NotificationResolver implementation.
+func (r *Resolver) Notification() NotificationResolver { return &notificationResolver{r} }
+
 // Query returns QueryResolver implementation.
 func (r *Resolver) Query() QueryResolver { return &queryResolver{r} }
 
+// Recurrence returns RecurrenceResolver implementation.
+func (r *Resolver) Recurrence() RecurrenceResolver { return &recurrenceResolver{r} }
+
+// Split returns SplitResolver implementation.
+func (r *Resolver) Split() SplitResolver { return &splitResolver{r} }
+
+// Subscription returns SubscriptionResolver implementation.
+func (r *Resolver) Subscription() SubscriptionResolver { return &subscriptionResolver{r} }
+
+// TagSummary returns TagSummaryResolver implementation.
+func (r *Resolver) TagSummary() TagSummaryResolver { return &tagSummaryResolver{r} }
+
+// TimeSeries returns TimeSeriesResolver implementation.
+func (r *Resolver) TimeSeries() TimeSeriesResolver { return &timeSeriesResolver{r} }
+
 // Transaction returns TransactionResolver implementation.
 func (r *Resolver) Transaction() TransactionResolver { return &transactionResolver{r} }
 
+// Transfer returns TransferResolver implementation.
+func (r *Resolver) Transfer() TransferResolver { return &transferResolver{r} }
+
+// Wallet returns WalletResolver implementation.
+func (r *Resolver) Wallet() WalletResolver { return &walletResolver{r} }
+
 type accountResolver struct{ *Resolver }
+type accountSummaryResolver struct{ *Resolver }
 type budgetResolver struct{ *Resolver }
 type categoryResolver struct{ *Resolver }
+type categorySummaryResolver struct{ *Resolver }
+type groupResolver struct{ *Resolver }
 type mutationResolver struct{ *Resolver }
+type notificationResolver struct{ *Resolver }
 type queryResolver struct{ *Resolver }
+type recurrenceResolver struct{ *Resolver }
+type splitResolver struct{ *Resolver }
+type subscriptionResolver struct{ *Resolver }
+type tagSummaryResolver struct{ *Resolver }
+type timeSeriesResolver struct{ *Resolver }
 type transactionResolver struct{ *Resolver }
+type transferResolver struct{ *Resolver }
+type walletResolver struct{ *Resolver }
@@ -12,6 +12,7 @@ import (
 	"finawise.app/server/config"
 	"finawise.app/server/container"
 	"finawise.app/server/handlers/middlewares"
+	"finawise.app/server/models"
 	"finawise.app/server/repository"
 	"finawise.app/server/services"
 	"finawise.app/server/services/account"
@@ -51,7 +52,8 @@ func (h *AuthHandler) handleRegister() httpx.HandlerFunc {
 		Email    string `json:"email" validate:"required,email"`
 		Password string `json:"password" validate:"required,min=8,max=30"`
 		Fullname string `json:"fullname" validate:"required,max=30,printascii"`
-		Key      string `json:"key" validate:"required,uuid"`
+		Key      string `json:"key" validate:"required_without=Invite,excluded_with=Invite,omitempty,uuid"`
+		Invite   string `json:"invite" validate:"required_without=Key,omitempty,len=16,alphanum"`
 	}
 	return func(req *httpx.Request, res *httpx.Responder) error {
 		var params Params
@@ -59,9 +61,9 @@ func (h *AuthHandler) handleRegister() httpx.HandlerFunc {
 			return err
 		}
 
-		a, err := h.account.Register(params.Email, params.Password, params.Fullname, params.Key)
+		a, err := h.account.Register(params.Email, params.Password, params.Fullname, params.Key, params.Invite)
 		if err != nil {
-			if err == account.ErrLicenseKey {
+			if err == account.ErrLicenseKey || err == account.ErrInvitation {
 				return httpx.ErrBadRequest.WithError(err)
 			}
 			var e *repository.Error
@@ -105,38 +107,47 @@ func (h *AuthHandler) handleLogin() httpx.HandlerFunc {
 			}
 		}
 
-		now := time.Now()
-		expire := now.AddDate(0, 1, 0) // 1 month
-		claims := &middlewares.SessionTokenClaims{
-			RegisteredClaims: jwt.RegisteredClaims{
-				IssuedAt:  jwt.NewNumericDate(now),
-				ExpiresAt: jwt.NewNumericDate(expire),
-			},
-			Session: account.Session{
-				AccountID: a.ID,
-				GroupID:   a.GroupID,
-			},
+		if err := setSession(req, res, h.config.Secret, a); err != nil {
+			return err
 		}
+		return res.Status(http.StatusOK).JSON(a, "")
+	}
+}
 
-		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-		signed, err := token.SignedString([]byte(h.config.Secret))
-		if err != nil {
-			return httpx.WrapHTTPError(err,
-				http.StatusUnprocessableEntity,
-				"failed to sign token",
-			)
-		}
+// setSession sets the cookie of a new session token of the account.
+func setSession(req *httpx.Request, res *httpx.Responder, secret string, a models.Account) error {
+	now := time.Now()
+	expire := now.AddDate(0, 1, 0) // 1 month
+	claims := &middlewares.SessionTokenClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expire),
+		},
+		Session: account.Session{
+			AccountID: a.ID,
+			GroupID:   a.GroupID,
+			Role:      a.Role,
+		},
+	}
 
-		res.SetCookie(&http.Cookie{
-			Name:     "token",
-			Value:    signed,
-			Path:     "/",
-			Expires:  expire.Add(1 * time.Minute),
-			Secure:   req.IsTLS(),
-			HttpOnly: true,
-		})
-		return res.Status(http.StatusOK).JSON(a, "")
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	signed, err := token.SignedString([]byte(secret))
+	if err != nil {
+		return httpx.WrapHTTPError(err,
+			http.StatusUnprocessableEntity,
+			"failed to sign token",
+		)
 	}
+
+	res.SetCookie(&http.Cookie{
+		Name:     "token",
+		Value:    signed,
+		Path:     "/",
+		Expires:  expire.Add(1 * time.Minute),
+		Secure:   req.IsTLS(),
+		HttpOnly: true,
+	})
+	return nil
 }
 
 func (h *AuthHandler) handleLogout() httpx.HandlerFunc {
@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/tnychn/httpx"
+
+	"finawise.app/server/config"
+	"finawise.app/server/container"
+	"finawise.app/server/handlers/middlewares"
+	"finawise.app/server/models"
+	"finawise.app/server/models/types"
+	"finawise.app/server/repository"
+	"finawise.app/server/services"
+	"finawise.app/server/services/account"
+)
+
+func init() {
+	Handlers = append(Handlers, newExportHandler)
+}
+
+type ExportHandler struct {
+	config  config.Config
+	repo    repository.Repository
+	account *services.AccountService
+}
+
+func newExportHandler(c *container.Container) Handler {
+	config := container.Use[config.Config](c, "config")
+	repo := container.Use[repository.Repository](c, "repository")
+	account := container.Use[*services.AccountService](c, "service/account")
+	return &ExportHandler{config: config, repo: repo, account: account}
+}
+
+func (h *ExportHandler) Mount(router *mux.Router) {
+	r := router.PathPrefix("/api/export").Subrouter()
+	r.Use(middlewares.RateLimit())
+	r.Use(middlewares.Session(h.config.Secret, true))
+	r.Use(middlewares.Membership(h.repo))
+	r.Handle("", h.handleExport()).
+		Methods(http.MethodGet, http.MethodOptions)
+	r.Handle("/archive", h.handleArchive()).
+		Methods(http.MethodGet, http.MethodOptions)
+}
+
+// flushEvery is the number of rows written before flushing the response.
+const flushEvery = 100
+
+// Exports outlast the write timeout of the server, so the write deadline is
+// pushed back by flushTimeout on every flush of an export, and set to
+// archiveTimeout for an archive as a whole.
+const (
+	flushTimeout   = 15 * time.Second
+	archiveTimeout = 5 * time.Minute
+)
+
+// extendDeadline allows the response d more time to be written from now.
+func extendDeadline(res *httpx.Responder, d time.Duration) error {
+	return http.NewResponseController(res.Writer).SetWriteDeadline(time.Now().Add(d))
+}
+
+// handleExport streams the transactions of the account that match the same
+// filters as the transaction listing, with timestamps in ISO 8601.
+func (h *ExportHandler) handleExport() httpx.HandlerFunc {
+	type Params struct {
+		Format     string               `query:"format" validate:"required,oneof=csv json"`
+		From       *types.Timestamp     `query:"from"`
+		To         *types.Timestamp     `query:"to"`
+		MinAmount  *types.Money         `query:"minAmount" validate:"omitempty,gte=0"`
+		MaxAmount  *types.Money         `query:"maxAmount" validate:"omitempty,gte=0"`
+		Title      *string              `query:"title" validate:"omitempty,max=30"`
+		Categories []types.ID           `query:"categories"`
+		Wallets    []types.ID           `query:"wallets"`
+		Tags       []types.ID           `query:"tags"`
+		TagMatch   models.TagMatch      `query:"tagMatch" validate:"omitempty,oneof=ANY ALL"`
+		Type       *models.CategoryType `query:"type" validate:"omitempty,oneof=INCOME EXPENSE"`
+		Order      models.SortOrder     `query:"order" validate:"omitempty,oneof=ASC DESC"`
+	}
+	return func(req *httpx.Request, res *httpx.Responder) error {
+		var params Params
+		if err := req.Bind(&params); err != nil {
+			return err
+		}
+		filter := models.TransactionFilter{
+			From:       params.From,
+			To:         params.To,
+			MinAmount:  params.MinAmount,
+			MaxAmount:  params.MaxAmount,
+			Title:      params.Title,
+			Categories: params.Categories,
+			Wallets:    params.Wallets,
+			Tags:       params.Tags,
+			TagMatch:   params.TagMatch,
+			Type:       params.Type,
+			Order:      params.Order,
+		}
+		session := req.GetValue("session").(account.Session)
+		name := "transactions-" + time.Now().UTC().Format("20060102") + "." + params.Format
+		res.Header().Set("Content-Disposition", `attachment; filename="`+name+`"`)
+
+		if err := extendDeadline(res, flushTimeout); err != nil {
+			return err
+		}
+		// the status cannot change once rows are written, so an error
+		// midway only truncates the export
+		n := 0
+		if params.Format == "csv" {
+			res.Header().Set("Content-Type", "text/csv; charset=utf-8")
+			res.Status(http.StatusOK)
+			w := csv.NewWriter(res)
+			w.Write([]string{"id", "timestamp", "title", "amount", "currency", "category", "type", "emoji", "wallet"})
+			err := h.repo.ExportTransactions(session.AccountID, filter, func(t models.TransactionExport) error {
+				w.Write([]string{
+					t.ID.String(),
+					t.Timestamp.UTC().Format(time.RFC3339),
+					t.Title,
+					t.Amount.String(),
+					t.Currency,
+					t.CategoryName,
+					string(t.CategoryType),
+					t.CategoryEmoji,
+					t.WalletName,
+				})
+				if n++; n%flushEvery == 0 {
+					w.Flush()
+					res.Flush()
+					if err := extendDeadline(res, flushTimeout); err != nil {
+						return err
+					}
+				}
+				return w.Error()
+			})
+			w.Flush()
+			if err != nil {
+				return err
+			}
+			return w.Error()
+		}
+
+		res.Header().Set("Content-Type", "application/json")
+		res.Status(http.StatusOK)
+		enc := json.NewEncoder(res)
+		if _, err := res.Write([]byte("[")); err != nil {
+			return err
+		}
+		err := h.repo.ExportTransactions(session.AccountID, filter, func(t models.TransactionExport) error {
+			if n > 0 {
+				if _, err := res.Write([]byte(",")); err != nil {
+					return err
+				}
+			}
+			t.Timestamp.Time = t.Timestamp.UTC()
+			if n++; n%flushEvery == 0 {
+				res.Flush()
+				if err := extendDeadline(res, flushTimeout); err != nil {
+					return err
+				}
+			}
+			return enc.Encode(t)
+		})
+		if err != nil {
+			return err
+		}
+		_, err = res.Write([]byte("]"))
+		return err
+	}
+}
+
+// handleArchive downloads an archive of everything in the group, which can be
+// restored by the archive import. Only the owner can, as the archive includes
+// the transactions of every member.
+func (h *ExportHandler) handleArchive() httpx.HandlerFunc {
+	return func(req *httpx.Request, res *httpx.Responder) error {
+		session := req.GetValue("session").(account.Session)
+		if !session.Role.Allows(models.RoleOwner) {
+			return httpx.ErrForbidden.WithError(account.ErrForbidden)
+		}
+		if err := extendDeadline(res, archiveTimeout); err != nil {
+			return err
+		}
+		archive, err := h.account.Export(session.GroupID)
+		if err != nil {
+			return err
+		}
+		name := "finawise-" + archive.Timestamp.Format("20060102") + ".json"
+		res.Header().Set("Content-Disposition", `attachment; filename="`+name+`"`)
+		return res.Status(http.StatusOK).JSON(archive, "")
+	}
+}
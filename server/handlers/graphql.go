@@ -4,6 +4,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/http"
+	"net/url"
+	"time"
 
 	gqlgen "github.com/99designs/gqlgen/graphql"
 	"github.com/99designs/gqlgen/graphql/handler"
@@ -13,6 +16,7 @@ import (
 	"github.com/99designs/gqlgen/graphql/playground"
 	"github.com/go-playground/validator/v10"
 	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
 	"github.com/vektah/gqlparser/v2/ast"
 	"github.com/vektah/gqlparser/v2/gqlerror"
 
@@ -20,8 +24,11 @@ import (
 	"finawise.app/server/container"
 	"finawise.app/server/graphql"
 	"finawise.app/server/handlers/middlewares"
+	"finawise.app/server/models"
 	"finawise.app/server/repository"
 	"finawise.app/server/services"
+	"finawise.app/server/services/account"
+	"finawise.app/server/services/currency"
 )
 
 var validate *validator.Validate
@@ -29,6 +36,10 @@ var validate *validator.Validate
 func init() {
 	Handlers = append(Handlers, newGraphQLHandler)
 	validate = validator.New(validator.WithRequiredStructEnabled())
+	// amounts are kept in hundredths, which not every currency has
+	validate.RegisterValidation("currency", func(fl validator.FieldLevel) bool {
+		return currency.Check(fl.Field().String()) == nil
+	})
 }
 
 type GraphQLHandler struct {
@@ -36,6 +47,8 @@ type GraphQLHandler struct {
 	config  config.Config
 	repo    repository.Repository
 	account *services.AccountService
+	alerts  *services.AlertService
+	broker  *services.Broker
 }
 
 func newGraphQLHandler(c *container.Container) Handler {
@@ -43,15 +56,27 @@ func newGraphQLHandler(c *container.Container) Handler {
 	config := container.Use[config.Config](c, "config")
 	repo := container.Use[repository.Repository](c, "repository")
 	account := container.Use[*services.AccountService](c, "service/account")
-	return &GraphQLHandler{debug: debug, config: config, repo: repo, account: account}
+	alerts := container.Use[*services.AlertService](c, "service/alerts")
+	broker := container.Use[*services.Broker](c, "service/broker")
+	return &GraphQLHandler{debug: debug, config: config, repo: repo, account: account, alerts: alerts, broker: broker}
 }
 
 func (h *GraphQLHandler) Mount(router *mux.Router) {
 	config := graphql.Config{
 		Resolvers: &graphql.Resolver{
 			Repository: h.repo,
+			Accounts:   h.account,
+			Alerts:     h.alerts,
+			Broker:     h.broker,
 		},
 	}
+	config.Directives.HasRole = func(ctx context.Context, obj any, next gqlgen.Resolver, role models.Role) (res any, err error) {
+		session, ok := ctx.Value("session").(account.Session)
+		if !ok || !session.Role.Allows(role) {
+			return nil, account.ErrForbidden
+		}
+		return next(ctx)
+	}
 	config.Directives.Validate = func(ctx context.Context, obj any, next gqlgen.Resolver, tag string) (res any, err error) {
 		res, err = next(ctx)
 		if err != nil {
@@ -69,6 +94,20 @@ func (h *GraphQLHandler) Mount(router *mux.Router) {
 	handler.AddTransport(transport.Options{})
 	handler.AddTransport(transport.GET{})
 	handler.AddTransport(transport.POST{})
+	handler.AddTransport(transport.Websocket{
+		KeepAlivePingInterval: 10 * time.Second,
+		Upgrader: websocket.Upgrader{
+			CheckOrigin: h.checkOrigin,
+		},
+		// the session is read from the cookie of the upgrade request,
+		// but only enforced here so that the client is told why
+		InitFunc: func(ctx context.Context, _ transport.InitPayload) (context.Context, *transport.InitPayload, error) {
+			if _, ok := ctx.Value("session").(account.Session); !ok {
+				return ctx, nil, errors.New("unauthorized")
+			}
+			return ctx, nil, nil
+		},
+	})
 	handler.Use(extension.Introspection{})
 
 	handler.SetQueryCache(lru.New[*ast.QueryDocument](1000))
@@ -88,12 +127,33 @@ func (h *GraphQLHandler) Mount(router *mux.Router) {
 			}
 		} else if errors.Is(err, repository.ErrNoRows) {
 			err.Message = "entity not found"
+		} else if errors.Is(err, account.ErrPassword) {
+			err.Message = "incorrect password"
 		}
 		return
 	})
 
 	r := router.PathPrefix("/api/graphql").Subrouter()
 	r.Use(middlewares.RateLimit())
-	r.Handle("", middlewares.Session(h.config.Secret, true)(handler))
+	membership := middlewares.Membership(h.repo)(handler)
+	r.Handle("", middlewares.Session(h.config.Secret, false)(membership)).Headers("Upgrade", "websocket")
+	r.Handle("", middlewares.Session(h.config.Secret, true)(membership))
 	r.Handle("/playground", playground.Handler("", "/api/graphql"))
 }
+
+// checkOrigin only accepts websockets from the origin of the server, or of
+// the configured URL, as the session cookie is sent along by browsers anyway.
+func (h *GraphQLHandler) checkOrigin(req *http.Request) bool {
+	origin := req.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	if u.Host == req.Host {
+		return true
+	}
+	return h.config.URL != nil && u.Scheme == h.config.URL.Scheme && u.Host == h.config.URL.Host
+}
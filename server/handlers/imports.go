@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/tnychn/httpx"
+
+	"finawise.app/server/config"
+	"finawise.app/server/container"
+	"finawise.app/server/handlers/middlewares"
+	"finawise.app/server/models"
+	"finawise.app/server/models/types"
+	"finawise.app/server/repository"
+	"finawise.app/server/services"
+	"finawise.app/server/services/account"
+	"finawise.app/server/services/importer"
+)
+
+func init() {
+	Handlers = append(Handlers, newImportHandler)
+}
+
+type ImportHandler struct {
+	config   config.Config
+	repo     repository.Repository
+	importer *services.ImportService
+	account  *services.AccountService
+}
+
+func newImportHandler(c *container.Container) Handler {
+	config := container.Use[config.Config](c, "config")
+	repo := container.Use[repository.Repository](c, "repository")
+	importer := container.Use[*services.ImportService](c, "service/import")
+	account := container.Use[*services.AccountService](c, "service/account")
+	return &ImportHandler{config: config, repo: repo, importer: importer, account: account}
+}
+
+func (h *ImportHandler) Mount(router *mux.Router) {
+	r := router.PathPrefix("/api/import").Subrouter()
+	r.Use(middlewares.RateLimit())
+	r.Use(middlewares.Session(h.config.Secret, true))
+	r.Use(middlewares.Membership(h.repo))
+	r.Handle("/csv", h.handleCSV()).
+		Methods(http.MethodPost, http.MethodOptions)
+	r.Handle("/ofx", h.handleOFX()).
+		Methods(http.MethodPost, http.MethodOptions)
+	r.Handle("/qif", h.handleQIF()).
+		Methods(http.MethodPost, http.MethodOptions)
+	r.Handle("/archive", h.handleArchive()).
+		Methods(http.MethodPost, http.MethodOptions)
+}
+
+// handleImport binds the options common to all statement formats, parses the
+// multipart file "file" and imports its rows.
+func (h *ImportHandler) handleImport(parse func(io.Reader) ([]importer.Row, error)) httpx.HandlerFunc {
+	type Params struct {
+		DefaultIncome    *types.ID `form:"defaultIncome"`
+		DefaultExpense   *types.ID `form:"defaultExpense"`
+		Wallet           *types.ID `form:"wallet"`
+		CreateCategories bool      `form:"createCategories"`
+		DryRun           bool      `query:"dryRun"`
+	}
+	return func(req *httpx.Request, res *httpx.Responder) error {
+		var params Params
+		if err := req.Bind(&params); err != nil {
+			return err
+		}
+		file, _, err := req.Request.FormFile("file")
+		if err != nil {
+			return httpx.ErrBadRequest.WithError(err)
+		}
+		defer file.Close()
+
+		rows, err := parse(file)
+		if err != nil {
+			if errors.Is(err, importer.ErrCSV) || errors.Is(err, importer.ErrOFX) || errors.Is(err, importer.ErrQIF) {
+				return httpx.ErrBadRequest.WithError(err)
+			}
+			return err
+		}
+
+		session := req.GetValue("session").(account.Session)
+		required := models.RoleEditor
+		if params.CreateCategories {
+			required = models.RoleOwner
+		}
+		if !session.Role.Allows(required) {
+			return httpx.ErrForbidden.WithError(account.ErrForbidden)
+		}
+		rep, err := h.importer.Import(session, rows, importer.Options{
+			DefaultIncome:    params.DefaultIncome,
+			DefaultExpense:   params.DefaultExpense,
+			Wallet:           params.Wallet,
+			CreateCategories: params.CreateCategories,
+			DryRun:           params.DryRun,
+		})
+		if err == importer.ErrWallet {
+			return httpx.ErrBadRequest.WithError(err)
+		}
+		if err != nil {
+			return err
+		}
+		// the report is unprocessable if any row failed
+		if rep.Failed > 0 {
+			return res.Status(http.StatusUnprocessableEntity).JSON(rep, "")
+		}
+		return res.Status(http.StatusOK).JSON(rep, "")
+	}
+}
+
+// handleCSV imports transactions from a CSV, with its columns mapped by the
+// form parameters.
+func (h *ImportHandler) handleCSV() httpx.HandlerFunc {
+	type Params struct {
+		Date        string `form:"date" validate:"required"`
+		DateFormat  string `form:"dateFormat"`
+		Amount      string `form:"amount" validate:"required"`
+		ExpenseSign string `form:"expenseSign" validate:"omitempty,oneof=- +"`
+		Title       string `form:"title" validate:"required"`
+		Category    string `form:"category"`
+		Currency    string `form:"currency"`
+	}
+	return func(req *httpx.Request, res *httpx.Responder) error {
+		var params Params
+		if err := req.Bind(&params); err != nil {
+			return err
+		}
+		return h.handleImport(func(r io.Reader) ([]importer.Row, error) {
+			return importer.ParseCSV(r, importer.CSVMapping{
+				Date:        params.Date,
+				DateFormat:  params.DateFormat,
+				Amount:      params.Amount,
+				ExpenseSign: params.ExpenseSign,
+				Title:       params.Title,
+				Category:    params.Category,
+				Currency:    params.Currency,
+			})
+		})(req, res)
+	}
+}
+
+// handleOFX imports transactions from an OFX statement.
+func (h *ImportHandler) handleOFX() httpx.HandlerFunc {
+	return h.handleImport(importer.ParseOFX)
+}
+
+// handleQIF imports transactions from a QIF file, with dates in the format
+// of the form parameter "dateFormat", if given.
+func (h *ImportHandler) handleQIF() httpx.HandlerFunc {
+	type Params struct {
+		DateFormat string `form:"dateFormat"`
+	}
+	return func(req *httpx.Request, res *httpx.Responder) error {
+		var params Params
+		if err := req.Bind(&params); err != nil {
+			return err
+		}
+		return h.handleImport(func(r io.Reader) ([]importer.Row, error) {
+			return importer.ParseQIF(r, params.DateFormat)
+		})(req, res)
+	}
+}
+
+// handleArchive restores the archive in the multipart file "file" into a new
+// group of the account, and renews the session for the group.
+func (h *ImportHandler) handleArchive() httpx.HandlerFunc {
+	return func(req *httpx.Request, res *httpx.Responder) error {
+		file, _, err := req.Request.FormFile("file")
+		if err != nil {
+			return httpx.ErrBadRequest.WithError(err)
+		}
+		defer file.Close()
+
+		session := req.GetValue("session").(account.Session)
+		a, err := h.account.Import(session.AccountID, file)
+		if err != nil {
+			if errors.Is(err, account.ErrArchive) {
+				return httpx.ErrBadRequest.WithError(err)
+			}
+			if err == repository.ErrNotEmpty {
+				return res.Status(http.StatusConflict).String(err.Error())
+			}
+			return err
+		}
+
+		if err := setSession(req, res, h.config.Secret, a); err != nil {
+			return err
+		}
+		return res.Status(http.StatusCreated).JSON(a, "")
+	}
+}
@@ -0,0 +1,35 @@
+package middlewares
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/tnychn/httpx"
+
+	"finawise.app/server/repository"
+	"finawise.app/server/services/account"
+)
+
+// Membership refreshes the group and role of the session from its account,
+// as they change whenever the account joins or leaves a group, long before
+// the session token expires. Sessions of deleted accounts are rejected.
+func Membership(repo repository.Repository) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return httpx.HandlerFunc(func(req *httpx.Request, res *httpx.Responder) error {
+			session, ok := req.GetValue("session").(account.Session)
+			if !ok {
+				return httpx.H(next)(req, res)
+			}
+			a, err := repo.GetAccount(session.AccountID)
+			if err != nil {
+				if err == repository.ErrNoRows {
+					return httpx.ErrUnauthorized
+				}
+				return err
+			}
+			session.GroupID, session.Role = a.GroupID, a.Role
+			req.SetValue("session", session)
+			return httpx.H(next)(req, res)
+		})
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"flag"
 	"fmt"
 	"net/http"
+	"os"
+	"strings"
 	"time"
 
 	"github.com/go-playground/validator/v10"
@@ -23,9 +25,18 @@ import (
 
 // TODO: use log/slog instead of zerolog
 
-const HTTPMaxBytes = 1 * 1024 * 1024 // 1MB
+const (
+	HTTPMaxBytes       = 1 * 1024 * 1024  // 1MB
+	HTTPMaxImportBytes = 16 * 1024 * 1024 // 16MB, for statements uploaded to /api/import
+)
+
+var (
+	debug = flag.Bool("debug", false, "enable debug mode")
+	rates = flag.String("rates", "", "import exchange rates from a csv file and exit")
 
-var debug = flag.Bool("debug", false, "enable debug mode")
+	migrateOnly   = flag.Bool("migrate-only", false, "apply pending database migrations and exit")
+	migrateStatus = flag.Bool("migrate-status", false, "list database migrations and whether they are applied, and exit")
+)
 
 type RequestBinder struct {
 	binder *httpx.DefaultRequestBinder
@@ -73,13 +84,30 @@ func init() {
 
 func main() {
 	config := config.MustLoad()
+
+	if *migrateOnly || *migrateStatus {
+		if err := migrate(config, *migrateStatus); err != nil {
+			log.Error().Str("from", "repository").Msg(err.Error())
+			os.Exit(1)
+		}
+		return
+	}
+
 	router := mux.NewRouter()
 
 	if config.URL != nil {
 		router.Use(middlewares.CORS(*config.URL))
 	}
 	router.Use(func(handler http.Handler) http.Handler {
-		return http.MaxBytesHandler(handler, HTTPMaxBytes)
+		limited := http.MaxBytesHandler(handler, HTTPMaxBytes)
+		importing := http.MaxBytesHandler(handler, HTTPMaxImportBytes)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if strings.HasPrefix(r.URL.Path, "/api/import/") {
+				importing.ServeHTTP(w, r)
+				return
+			}
+			limited.ServeHTTP(w, r)
+		})
 	})
 
 	router.NotFoundHandler = httpx.HandlerFunc(func(req *httpx.Request, res *httpx.Responder) error {
@@ -99,6 +127,11 @@ func main() {
 	})
 	container.Set(c, "debug", *debug)
 	container.Provide(c, "service/account", services.NewAccountService)
+	container.Provide(c, "service/alerts", services.NewAlertService)
+	container.Provide(c, "service/currency", services.NewCurrencyService)
+	container.Provide(c, "service/import", services.NewImportService)
+	container.Provide(c, "service/broker", services.NewBroker)
+	container.Provide(c, "service/scheduler", services.NewScheduler)
 
 	for _, provider := range handlers.Handlers {
 		provider(c).Mount(router)
@@ -119,6 +152,13 @@ func main() {
 		}
 	}()
 
+	if *rates != "" {
+		if err := importRates(c, *rates); err != nil {
+			log.Error().Str("from", *rates).Msg(err.Error())
+		}
+		return
+	}
+
 	server := http.Server{
 		Addr:         config.ServerAddress(),
 		Handler:      router,
@@ -134,3 +174,47 @@ func main() {
 		}
 	}
 }
+
+func importRates(c *container.Container, name string) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	currency := container.Use[*services.CurrencyService](c, "service/currency")
+	n, err := currency.ImportRates(f)
+	if err != nil {
+		return err
+	}
+	log.Info().Msgf("imported %d exchange rates", n)
+	return nil
+}
+
+// migrate applies the pending migrations, or only lists every migration with
+// the time it was applied if status is set.
+func migrate(config config.Config, status bool) error {
+	if !status {
+		applied, err := repository.Migrate(config)
+		for _, m := range applied {
+			log.Info().Msgf("applied migration %04d %s", m.Version, m.Name)
+		}
+		if err == nil && len(applied) == 0 {
+			log.Info().Msg("database is up to date")
+		}
+		return err
+	}
+
+	ms, err := repository.MigrationStatus(config)
+	if err != nil {
+		return err
+	}
+	for _, m := range ms {
+		applied := "pending"
+		if m.AppliedAt != nil {
+			applied = m.AppliedAt.UTC().Format(time.RFC3339)
+		}
+		fmt.Printf("%04d  %-20s  %s\n", m.Version, m.Name, applied)
+	}
+	return nil
+}
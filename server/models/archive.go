@@ -0,0 +1,25 @@
+package models
+
+import "finawise.app/server/models/types"
+
+// ArchiveVersion is the version of the archives written by this server.
+// Archives of later versions cannot be imported.
+const ArchiveVersion = 4
+
+// Archive is everything a group owns, as exported for a backup. IDs are only
+// meaningful within the archive, as they are replaced when it is imported.
+type Archive struct {
+	Version         int              `json:"version"`
+	Timestamp       types.Timestamp  `json:"timestamp"`
+	Group           Group            `json:"group"`
+	Categories      []Category       `json:"categories"`
+	Wallets         []Wallet         `json:"wallets"` // since version 3
+	Transactions    []Transaction    `json:"transactions"`
+	Splits          []Split          `json:"splits"` // since version 2
+	Budgets         []Budget         `json:"budgets"`
+	Recurrences     []Recurrence     `json:"recurrences"`
+	Transfers       []Transfer       `json:"transfers"`       // since version 3
+	Tags            []Tag            `json:"tags"`            // since version 4
+	TransactionTags []TransactionTag `json:"transactionTags"` // since version 4
+	Notifications   []Notification   `json:"notifications"`
+}
@@ -0,0 +1,35 @@
+package models
+
+import "finawise.app/server/models/types"
+
+type ChangeAction string
+
+const (
+	ChangeActionCreated ChangeAction = "CREATED"
+	ChangeActionUpdated ChangeAction = "UPDATED"
+	ChangeActionDeleted ChangeAction = "DELETED"
+)
+
+// TransactionChange is published when a transaction of a group changes.
+// Transaction is nil when it has been deleted.
+type TransactionChange struct {
+	Action      ChangeAction `json:"action"`
+	ID          types.ID     `json:"id"`
+	Transaction *Transaction `json:"transaction"`
+}
+
+// BudgetChange is published when the budget of a category changes.
+// Budget is nil when it has been removed.
+type BudgetChange struct {
+	Action     ChangeAction `json:"action"`
+	CategoryID types.ID     `json:"cid"`
+	Budget     *Budget      `json:"budget"`
+}
+
+// CategoryChange is published when a category of a group changes.
+// Category is nil when it has been deleted.
+type CategoryChange struct {
+	Action   ChangeAction `json:"action"`
+	ID       types.ID     `json:"id"`
+	Category *Category    `json:"category"`
+}
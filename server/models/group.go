@@ -0,0 +1,35 @@
+package models
+
+import "finawise.app/server/models/types"
+
+// Role is the permission of an account in its group. Each role is allowed
+// everything the roles below it are.
+type Role string
+
+const (
+	RoleOwner  Role = "OWNER"  // manages the group, its members, categories and budgets
+	RoleEditor Role = "EDITOR" // records transactions of its own account
+	RoleViewer Role = "VIEWER" // only views
+)
+
+var roleRanks = map[Role]int{
+	RoleViewer: 1,
+	RoleEditor: 2,
+	RoleOwner:  3,
+}
+
+// Allows reports whether the role is allowed what the required role is.
+func (r Role) Allows(required Role) bool {
+	return roleRanks[r] >= roleRanks[required]
+}
+
+// Invitation lets another account join the group with the role until it
+// expires, and can be accepted only once.
+type Invitation struct {
+	Code      string          `db:"code" json:"code"`
+	GroupID   int64           `db:"group_id" json:"-"`
+	Role      Role            `db:"role" json:"role"`
+	CreatedBy int64           `db:"created_by" json:"createdBy"`
+	CreatedAt types.Timestamp `db:"created_at" json:"createdAt"`
+	ExpiresAt types.Timestamp `db:"expires_at" json:"expiresAt"`
+}
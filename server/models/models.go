@@ -9,21 +9,67 @@ const (
 	CategoryTypeExpense CategoryType = "EXPENSE"
 )
 
+type SortOrder string
+
+const (
+	SortOrderAsc  SortOrder = "ASC"
+	SortOrderDesc SortOrder = "DESC"
+)
+
 type Account struct {
 	ID       int64  `db:"id" json:"id"`
 	GroupID  int64  `db:"group_id" json:"gid"`
 	Email    string `db:"email" json:"email"`
 	Fullname string `db:"fullname" json:"fullname"`
 	Passhash string `db:"passhash" json:"-"`
+	Role     Role   `db:"role" json:"role"`
 }
 
+// AccountSummary totals the transactions of an account within [From, To),
+// where either bound may be open.
 type AccountSummary struct {
-	Income  float64 `json:"income"`
-	Expense float64 `json:"expense"`
+	AccountID int64            `db:"-" json:"-"`
+	From      *types.Timestamp `db:"-" json:"from"`
+	To        *types.Timestamp `db:"-" json:"to"`
+	Currency  string           `db:"currency" json:"currency"`
+	Income    types.Money      `db:"income" json:"income"`
+	Expense   types.Money      `db:"expense" json:"expense"`
+}
+
+func (as AccountSummary) Net() types.Money {
+	return as.Income - as.Expense
+}
+
+// PreviousRange returns the range of the period of the same length that ends
+// where the summary starts. It is only defined when both bounds are set.
+func (as AccountSummary) PreviousRange() (from, to types.Timestamp, ok bool) {
+	if as.From == nil || as.To == nil {
+		return
+	}
+	length := as.To.Sub(as.From.Time)
+	return types.Timestamp{Time: as.From.Add(-length)}, *as.From, true
+}
+
+// CategorySummary totals the transactions of a category within the range of
+// an AccountSummary. Share is the fraction of the total of its category type.
+type CategorySummary struct {
+	CategoryID types.ID     `db:"category_id" json:"cid"`
+	Type       CategoryType `db:"type" json:"type"`
+	Total      types.Money  `db:"total" json:"total"`
+	Count      int          `db:"count" json:"count"`
+	Share      float64      `db:"-" json:"share"`
 }
 
 type Group struct {
-	ID int64 `db:"id" json:"id"`
+	ID       int64  `db:"id" json:"id"`
+	Currency string `db:"currency" json:"currency"`
+}
+
+type ExchangeRate struct {
+	Date string  `db:"date" json:"date"`
+	From string  `db:"from" json:"from"`
+	To   string  `db:"to" json:"to"`
+	Rate float64 `db:"rate" json:"rate"`
 }
 
 type Category struct {
@@ -36,15 +82,110 @@ type Category struct {
 }
 
 type Budget struct {
-	Amount     float64  `db:"amount" json:"amount"`
-	CategoryID types.ID `db:"category_id" json:"cid"`
+	Amount     types.Money     `db:"amount" json:"amount"`
+	Period     BudgetPeriod    `db:"period" json:"period"`
+	Anchor     types.Timestamp `db:"anchor" json:"anchor"`
+	CategoryID types.ID        `db:"category_id" json:"cid"`
+	Thresholds Thresholds      `db:"thresholds" json:"thresholds"`
+}
+
+type BudgetSpending struct {
+	PeriodStart types.Timestamp `json:"periodStart"`
+	PeriodEnd   types.Timestamp `json:"periodEnd"`
+	Amount      types.Money     `json:"amount"`
+	Spent       types.Money     `json:"spent"`
+}
+
+func (s BudgetSpending) Remaining() types.Money {
+	return s.Amount - s.Spent
 }
 
 type Transaction struct {
-	ID         types.ID        `db:"id" json:"id"`
-	AccountID  int64           `db:"account_id" json:"aid"`
-	CategoryID types.ID        `db:"category_id" json:"cid"`
-	Title      string          `db:"title" json:"title"`
-	Amount     float64         `db:"amount" json:"amount"`
-	Timestamp  types.Timestamp `db:"timestamp" json:"timestamp"`
+	ID           types.ID        `db:"id" json:"id"`
+	AccountID    int64           `db:"account_id" json:"aid"`
+	CategoryID   types.ID        `db:"category_id" json:"cid"`
+	WalletID     types.ID        `db:"wallet_id" json:"wid"`
+	Title        string          `db:"title" json:"title"`
+	Amount       types.Money     `db:"amount" json:"amount"`
+	Timestamp    types.Timestamp `db:"timestamp" json:"timestamp"`
+	Currency     string          `db:"currency" json:"currency"`
+	RecurrenceID types.ID        `db:"recurrence_id" json:"rid"`
+	ExternalID   *string         `db:"external_id" json:"fitid"` // set if imported from a bank statement
+}
+
+// Split is a line of a transaction split across categories. The amounts of
+// the splits of a transaction add up to its amount, in its currency.
+type Split struct {
+	ID            types.ID    `db:"id" json:"id"`
+	TransactionID types.ID    `db:"transaction_id" json:"tid"`
+	CategoryID    types.ID    `db:"category_id" json:"cid"`
+	Amount        types.Money `db:"amount" json:"amount"`
+}
+
+// TransactionExport is a transaction with the details of its category and
+// the name of its wallet.
+type TransactionExport struct {
+	Transaction
+	CategoryName  string       `db:"category_name" json:"category"`
+	CategoryType  CategoryType `db:"category_type" json:"type"`
+	CategoryEmoji string       `db:"category_emoji" json:"emoji"`
+	WalletName    string       `db:"wallet_name" json:"wallet"`
+}
+
+type TransactionFilter struct {
+	From       *types.Timestamp `json:"from"`
+	To         *types.Timestamp `json:"to"`
+	MinAmount  *types.Money     `json:"minAmount"`
+	MaxAmount  *types.Money     `json:"maxAmount"`
+	Title      *string          `json:"title"`
+	Categories []types.ID       `json:"categories"`
+	Wallets    []types.ID       `json:"wallets"`
+	Tags       []types.ID       `json:"tags"`
+	TagMatch   TagMatch         `json:"tagMatch"`
+	Type       *CategoryType    `json:"type"`
+	Order      SortOrder        `json:"order"`
+}
+
+// Pages hold DefaultPageSize items unless their size is given, which is
+// capped at MaxPageSize.
+const (
+	DefaultPageSize = 50
+	MaxPageSize     = 500
+)
+
+type Page struct {
+	First  *int
+	After  *types.Cursor
+	Last   *int
+	Before *types.Cursor
+}
+
+type PageInfo struct {
+	HasNextPage     bool          `json:"hasNextPage"`
+	HasPreviousPage bool          `json:"hasPreviousPage"`
+	StartCursor     *types.Cursor `json:"startCursor"`
+	EndCursor       *types.Cursor `json:"endCursor"`
+}
+
+type TransactionEdge struct {
+	Cursor types.Cursor `json:"cursor"`
+	Node   Transaction  `json:"node"`
+}
+
+type TransactionConnection struct {
+	Edges      []TransactionEdge `json:"edges"`
+	PageInfo   PageInfo          `json:"pageInfo"`
+	TotalCount int               `json:"totalCount"`
+}
+
+type SearchEdge struct {
+	Cursor  types.Cursor `json:"cursor"`
+	Node    Transaction  `json:"node"`
+	Snippet string       `json:"snippet"`
+}
+
+type SearchConnection struct {
+	Edges      []SearchEdge `json:"edges"`
+	PageInfo   PageInfo     `json:"pageInfo"`
+	TotalCount int          `json:"totalCount"`
 }
@@ -0,0 +1,69 @@
+package models
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"finawise.app/server/models/types"
+)
+
+// Thresholds are percentages of a budget amount, stored as a comma-separated
+// list in ascending order.
+type Thresholds []int
+
+func (ts Thresholds) Value() (driver.Value, error) {
+	ts = slices.Clone(ts)
+	slices.Sort(ts)
+	ts = slices.Compact(ts)
+	s := make([]string, len(ts))
+	for i, t := range ts {
+		s[i] = strconv.Itoa(t)
+	}
+	return strings.Join(s, ","), nil
+}
+
+func (ts *Thresholds) Scan(src any) error {
+	x, ok := src.(string)
+	if !ok {
+		return fmt.Errorf("thresholds: source value must be a string")
+	}
+	*ts = Thresholds{}
+	for _, s := range strings.Split(x, ",") {
+		if s == "" {
+			continue
+		}
+		t, err := strconv.Atoi(s)
+		if err != nil {
+			return err
+		}
+		*ts = append(*ts, t)
+	}
+	return nil
+}
+
+// Crossed returns the thresholds reached by spending spent out of amount.
+func (ts Thresholds) Crossed(spent, amount types.Money) (crossed []int) {
+	for _, t := range ts {
+		if int64(spent)*100 >= int64(amount)*int64(t) {
+			crossed = append(crossed, t)
+		}
+	}
+	return
+}
+
+// Notification alerts a group that the spending of a budget has reached
+// Threshold percent of its amount in the period starting at PeriodStart.
+type Notification struct {
+	ID          types.ID        `db:"id" json:"id"`
+	GroupID     int64           `db:"group_id" json:"-"`
+	CategoryID  types.ID        `db:"category_id" json:"cid"`
+	Threshold   int             `db:"threshold" json:"threshold"`
+	PeriodStart types.Timestamp `db:"period_start" json:"periodStart"`
+	Amount      types.Money     `db:"amount" json:"amount"`
+	Spent       types.Money     `db:"spent" json:"spent"`
+	Timestamp   types.Timestamp `db:"timestamp" json:"timestamp"`
+	Read        bool            `db:"read" json:"read"`
+}
@@ -0,0 +1,66 @@
+package models
+
+import (
+	"time"
+
+	"finawise.app/server/models/types"
+)
+
+type BudgetPeriod string
+
+const (
+	BudgetPeriodWeekly    BudgetPeriod = "WEEKLY"
+	BudgetPeriodMonthly   BudgetPeriod = "MONTHLY"
+	BudgetPeriodQuarterly BudgetPeriod = "QUARTERLY"
+	BudgetPeriodYearly    BudgetPeriod = "YEARLY"
+)
+
+// DefaultAnchor aligns periods with calendar weeks (starting on Monday),
+// months, quarters and years.
+func (p BudgetPeriod) DefaultAnchor() types.Timestamp {
+	if p == BudgetPeriodWeekly {
+		return types.Timestamp{Time: time.Date(1970, 1, 5, 0, 0, 0, 0, time.UTC)}
+	}
+	return types.Timestamp{Time: time.Unix(0, 0).UTC()}
+}
+
+// Bounds returns the half-open range [start, end) of the period that
+// contains t, where periods repeat from the anchor onwards (and backwards).
+// Periods are computed in UTC.
+func (p BudgetPeriod) Bounds(anchor, t time.Time) (start, end time.Time) {
+	anchor, t = anchor.UTC(), t.UTC()
+	if p == BudgetPeriodWeekly {
+		const week = 7 * 24 * time.Hour
+		n := t.Sub(anchor) / week
+		if t.Before(anchor) && t.Sub(anchor)%week != 0 {
+			n--
+		}
+		start = anchor.Add(n * week)
+		return start, start.Add(week)
+	}
+
+	months := map[BudgetPeriod]int{
+		BudgetPeriodMonthly:   1,
+		BudgetPeriodQuarterly: 3,
+		BudgetPeriodYearly:    12,
+	}[p]
+	elapsed := (t.Year()-anchor.Year())*12 + int(t.Month()-anchor.Month())
+	n := elapsed / months
+	if elapsed%months != 0 && elapsed < 0 {
+		n--
+	}
+	// the month arithmetic above ignores days, so step back if needed
+	if addMonths(anchor, n*months).After(t) {
+		n--
+	}
+	return addMonths(anchor, n*months), addMonths(anchor, (n+1)*months)
+}
+
+// addMonths adds n months to t, clamping the day to the end of the month
+// instead of overflowing into the next one.
+func addMonths(t time.Time, n int) time.Time {
+	y, m, d := t.Date()
+	first := time.Date(y, m+time.Month(n), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	last := first.AddDate(0, 1, -1).Day()
+	return first.AddDate(0, 0, min(d, last)-1)
+}
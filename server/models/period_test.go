@@ -0,0 +1,55 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBudgetPeriodBounds(t *testing.T) {
+	date := func(y int, m time.Month, d int) time.Time {
+		return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	}
+	monthly := BudgetPeriodMonthly.DefaultAnchor().Time
+	weekly := BudgetPeriodWeekly.DefaultAnchor().Time
+
+	tests := []struct {
+		name       string
+		period     BudgetPeriod
+		anchor, t  time.Time
+		start, end time.Time
+	}{
+		{"calendar month", BudgetPeriodMonthly, monthly, date(2024, 5, 10), date(2024, 5, 1), date(2024, 6, 1)},
+		{"start of month", BudgetPeriodMonthly, monthly, date(2024, 3, 1), date(2024, 3, 1), date(2024, 4, 1)},
+		{"end of month", BudgetPeriodMonthly, monthly, date(2024, 3, 1).Add(-time.Second), date(2024, 2, 1), date(2024, 3, 1)},
+		{"calendar week", BudgetPeriodWeekly, weekly, date(2024, 1, 3), date(2024, 1, 1), date(2024, 1, 8)},
+		{"week before anchor", BudgetPeriodWeekly, date(2024, 1, 1), date(2023, 12, 27), date(2023, 12, 25), date(2024, 1, 1)},
+		{"calendar quarter", BudgetPeriodQuarterly, monthly, date(2024, 5, 10), date(2024, 4, 1), date(2024, 7, 1)},
+		{"calendar year", BudgetPeriodYearly, monthly, date(2024, 12, 31), date(2024, 1, 1), date(2025, 1, 1)},
+
+		// anchors on the 31st are clamped to the end of shorter months
+		{"31st into february", BudgetPeriodMonthly, date(2024, 1, 31), date(2024, 2, 15), date(2024, 1, 31), date(2024, 2, 29)},
+		{"31st from february", BudgetPeriodMonthly, date(2024, 1, 31), date(2024, 3, 15), date(2024, 2, 29), date(2024, 3, 31)},
+		{"31st on leap day", BudgetPeriodMonthly, date(2024, 1, 31), date(2024, 2, 29), date(2024, 2, 29), date(2024, 3, 31)},
+		{"31st into april", BudgetPeriodMonthly, date(2024, 1, 31), date(2024, 4, 30), date(2024, 4, 30), date(2024, 5, 31)},
+		{"31st before anchor", BudgetPeriodMonthly, date(2024, 1, 31), date(2023, 12, 15), date(2023, 11, 30), date(2023, 12, 31)},
+		{"31st quarter", BudgetPeriodQuarterly, date(2024, 1, 31), date(2024, 6, 1), date(2024, 4, 30), date(2024, 7, 31)},
+
+		// leap day anchors fall on 28 February in common years
+		{"leap day within year", BudgetPeriodYearly, date(2024, 2, 29), date(2025, 2, 27), date(2024, 2, 29), date(2025, 2, 28)},
+		{"leap day in common year", BudgetPeriodYearly, date(2024, 2, 29), date(2025, 2, 28), date(2025, 2, 28), date(2026, 2, 28)},
+		{"leap day in next leap year", BudgetPeriodYearly, date(2024, 2, 29), date(2028, 3, 1), date(2028, 2, 29), date(2029, 2, 28)},
+		{"leap day monthly", BudgetPeriodMonthly, date(2024, 2, 29), date(2024, 3, 28), date(2024, 2, 29), date(2024, 3, 29)},
+	}
+	for _, tt := range tests {
+		start, end := tt.period.Bounds(tt.anchor, tt.t)
+		if !start.Equal(tt.start) || !end.Equal(tt.end) {
+			t.Errorf("%s: Bounds(%s, %s) = [%s, %s), want [%s, %s)", tt.name,
+				tt.anchor.Format(time.DateOnly), tt.t.Format(time.DateTime),
+				start.Format(time.DateOnly), end.Format(time.DateOnly),
+				tt.start.Format(time.DateOnly), tt.end.Format(time.DateOnly))
+		}
+		if tt.t.Before(start) || !tt.t.Before(end) {
+			t.Errorf("%s: %s is outside of [%s, %s)", tt.name, tt.t, start, end)
+		}
+	}
+}
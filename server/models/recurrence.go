@@ -0,0 +1,105 @@
+package models
+
+import (
+	"time"
+
+	"finawise.app/server/models/types"
+)
+
+type Frequency string
+
+const (
+	FrequencyDaily   Frequency = "DAILY"
+	FrequencyWeekly  Frequency = "WEEKLY"
+	FrequencyMonthly Frequency = "MONTHLY"
+	FrequencyYearly  Frequency = "YEARLY"
+)
+
+// Recurrence is a rule that creates a transaction every Interval units of
+// Frequency from Start, until either Until or Count occurrences is reached.
+type Recurrence struct {
+	ID         types.ID         `db:"id" json:"id"`
+	AccountID  int64            `db:"account_id" json:"aid"`
+	CategoryID types.ID         `db:"category_id" json:"cid"`
+	WalletID   types.ID         `db:"wallet_id" json:"wid"`
+	Title      string           `db:"title" json:"title"`
+	Amount     types.Money      `db:"amount" json:"amount"`
+	Currency   string           `db:"currency" json:"currency"`
+	Frequency  Frequency        `db:"frequency" json:"frequency"`
+	Interval   int              `db:"interval" json:"interval"`
+	Start      types.Timestamp  `db:"start" json:"start"`
+	Until      *types.Timestamp `db:"until" json:"until"`
+	Count      *int             `db:"count" json:"count"`
+	Next       int              `db:"next" json:"next"`      // index of the next occurrence to create
+	NextAt     *types.Timestamp `db:"next_at" json:"nextAt"` // time of the next occurrence, if any
+}
+
+// Occurrence returns the time of the n-th occurrence, counting from 0.
+// Occurrences are computed in UTC.
+func (r Recurrence) Occurrence(n int) time.Time {
+	start := r.Start.UTC()
+	switch r.Frequency {
+	case FrequencyWeekly:
+		return start.AddDate(0, 0, 7*n*r.Interval)
+	case FrequencyMonthly:
+		return addMonths(start, n*r.Interval)
+	case FrequencyYearly:
+		return addMonths(start, 12*n*r.Interval)
+	default:
+		return start.AddDate(0, 0, n*r.Interval)
+	}
+}
+
+// Ended reports whether the n-th occurrence is beyond the end of the rule.
+func (r Recurrence) Ended(n int) bool {
+	if r.Count != nil && n >= *r.Count {
+		return true
+	}
+	return r.Until != nil && r.Occurrence(n).After(r.Until.Time)
+}
+
+// Upcoming returns the times of at most limit occurrences yet to be created.
+func (r Recurrence) Upcoming(limit int) []types.Timestamp {
+	upcoming := []types.Timestamp{}
+	for n := r.Next; len(upcoming) < limit && !r.Ended(n); n++ {
+		upcoming = append(upcoming, types.Timestamp{Time: r.Occurrence(n)})
+	}
+	return upcoming
+}
+
+// Schedule sets NextAt to the time of the next occurrence to create.
+func (r *Recurrence) Schedule() {
+	r.NextAt = nil
+	if !r.Ended(r.Next) {
+		r.NextAt = &types.Timestamp{Time: r.Occurrence(r.Next)}
+	}
+}
+
+// MaxDue limits the occurrences created at once, so that a rule started long
+// ago is caught up over several runs of the scheduler.
+const MaxDue = 100
+
+// Due returns the transactions of at most MaxDue occurrences up to now that
+// are yet to be created, and advances the rule past them.
+func (r *Recurrence) Due(now time.Time) (txns []Transaction) {
+	for len(txns) < MaxDue && !r.Ended(r.Next) && !r.Occurrence(r.Next).After(now) {
+		txns = append(txns, r.Transaction(r.Next))
+		r.Next++
+	}
+	r.Schedule()
+	return
+}
+
+// Transaction returns the transaction of the n-th occurrence.
+func (r Recurrence) Transaction(n int) Transaction {
+	return Transaction{
+		AccountID:    r.AccountID,
+		CategoryID:   r.CategoryID,
+		WalletID:     r.WalletID,
+		Title:        r.Title,
+		Amount:       r.Amount,
+		Timestamp:    types.Timestamp{Time: r.Occurrence(n)},
+		Currency:     r.Currency,
+		RecurrenceID: r.ID,
+	}
+}
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"finawise.app/server/models/types"
+)
+
+func TestRecurrenceDue(t *testing.T) {
+	start := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+	now := start.AddDate(0, 0, 250) // 251 occurrences due, counting the start
+	r := Recurrence{Frequency: FrequencyDaily, Interval: 1, Start: types.Timestamp{Time: start}}
+
+	for _, want := range []int{MaxDue, MaxDue, 51, 0} {
+		txns := r.Due(now)
+		if len(txns) != want {
+			t.Fatalf("Due = %d occurrences, want %d", len(txns), want)
+		}
+		if r.NextAt == nil || !r.NextAt.Equal(r.Occurrence(r.Next)) {
+			t.Fatalf("NextAt = %v, want %v", r.NextAt, r.Occurrence(r.Next))
+		}
+	}
+	if r.Next != 251 || !r.NextAt.After(now) {
+		t.Errorf("Next = %d at %v, want 251 after %v", r.Next, r.NextAt, now)
+	}
+}
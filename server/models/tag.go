@@ -0,0 +1,38 @@
+package models
+
+import "finawise.app/server/models/types"
+
+// Tag marks transactions of the group across categories. Names are unique
+// within the group regardless of case.
+type Tag struct {
+	ID      types.ID `db:"id" json:"id"`
+	GroupID int64    `db:"group_id" json:"gid"`
+	Name    string   `db:"name" json:"name"`
+}
+
+// TransactionTag links a transaction to a tag.
+type TransactionTag struct {
+	TransactionID types.ID `db:"transaction_id" json:"tid"`
+	TagID         types.ID `db:"tag_id" json:"tag"`
+}
+
+// TagMatch is how transactions are matched against a set of tags.
+type TagMatch string
+
+const (
+	TagMatchAny TagMatch = "ANY" // transactions with any of the tags
+	TagMatchAll TagMatch = "ALL" // transactions with all of the tags
+)
+
+// TagSummary totals the transactions with a tag within the range of an
+// AccountSummary.
+type TagSummary struct {
+	TagID   types.ID    `db:"tag_id" json:"tag"`
+	Income  types.Money `db:"income" json:"income"`
+	Expense types.Money `db:"expense" json:"expense"`
+	Count   int         `db:"count" json:"count"`
+}
+
+func (ts TagSummary) Net() types.Money {
+	return ts.Income - ts.Expense
+}
@@ -0,0 +1,67 @@
+package models
+
+import (
+	"time"
+
+	"finawise.app/server/models/types"
+)
+
+type Interval string
+
+const (
+	IntervalDay   Interval = "DAY"
+	IntervalWeek  Interval = "WEEK"
+	IntervalMonth Interval = "MONTH"
+	IntervalYear  Interval = "YEAR"
+)
+
+// Truncate returns the start of the interval that contains t. Weeks start on
+// Monday, and intervals are computed in UTC.
+func (i Interval) Truncate(t time.Time) time.Time {
+	y, m, d := t.UTC().Date()
+	switch i {
+	case IntervalWeek:
+		weekday := (int(t.UTC().Weekday()) + 6) % 7 // days since Monday
+		return time.Date(y, m, d-weekday, 0, 0, 0, 0, time.UTC)
+	case IntervalMonth:
+		return time.Date(y, m, 1, 0, 0, 0, 0, time.UTC)
+	case IntervalYear:
+		return time.Date(y, 1, 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	}
+}
+
+// Next returns the start of the interval after the one that starts at t.
+func (i Interval) Next(t time.Time) time.Time {
+	switch i {
+	case IntervalWeek:
+		return t.AddDate(0, 0, 7)
+	case IntervalMonth:
+		return t.AddDate(0, 1, 0)
+	case IntervalYear:
+		return t.AddDate(1, 0, 0)
+	default:
+		return t.AddDate(0, 0, 1)
+	}
+}
+
+type TimeSeriesGroup string
+
+const (
+	TimeSeriesGroupCategory TimeSeriesGroup = "CATEGORY"
+	TimeSeriesGroupType     TimeSeriesGroup = "TYPE"
+)
+
+// TimeSeries is the total amount of transactions in each interval, of either
+// a category or a category type.
+type TimeSeries struct {
+	Type       CategoryType      `json:"type"`
+	CategoryID *types.ID         `json:"cid"` // only when grouped by category
+	Points     []TimeSeriesPoint `json:"points"`
+}
+
+type TimeSeriesPoint struct {
+	Timestamp types.Timestamp `json:"timestamp"` // start of the interval
+	Amount    types.Money     `json:"amount"`
+}
@@ -0,0 +1,67 @@
+package types
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+var errMalformedCursor = fmt.Errorf("cursor: malformed value")
+
+// Cursor is an opaque position in a list. Lists ordered by (timestamp, id)
+// are keyed by the last row seen, whereas ranked lists without a stable key
+// use the offset of the next row instead.
+type Cursor struct {
+	Timestamp Timestamp
+	ID        ID
+	Offset    int
+}
+
+// IsOffset reports whether the cursor is an offset rather than a key.
+func (c Cursor) IsOffset() bool {
+	return c.ID.IsZero()
+}
+
+func (c Cursor) String() string {
+	s := strconv.Itoa(c.Offset)
+	if !c.IsOffset() {
+		s = fmt.Sprintf("%d:%s", c.Timestamp.Unix(), c.ID)
+	}
+	return base64.RawURLEncoding.EncodeToString([]byte(s))
+}
+
+func (c *Cursor) UnmarshalText(text []byte) error {
+	b, err := base64.RawURLEncoding.DecodeString(string(text))
+	if err != nil {
+		return errMalformedCursor
+	}
+	ts, id, ok := strings.Cut(string(b), ":")
+	if !ok {
+		if c.Offset, err = strconv.Atoi(ts); err != nil || c.Offset < 0 {
+			return errMalformedCursor
+		}
+		return nil
+	}
+	i, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return errMalformedCursor
+	}
+	if err := c.Timestamp.Scan(i); err != nil {
+		return err
+	}
+	return c.ID.UnmarshalText([]byte(id))
+}
+
+func (c *Cursor) UnmarshalGQL(v any) error {
+	s, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("cursor: value must be a string")
+	}
+	return c.UnmarshalText([]byte(s))
+}
+
+func (c Cursor) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(c.String()))
+}
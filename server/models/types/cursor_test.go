@@ -0,0 +1,51 @@
+package types
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		in   Cursor
+	}{
+		{"key", Cursor{Timestamp: Timestamp{Time: time.Unix(1704067200, 0)}, ID: MakeID()}},
+		{"key before epoch", Cursor{Timestamp: Timestamp{Time: time.Unix(-86400, 0)}, ID: MakeID()}},
+		{"offset", Cursor{Offset: 42}},
+		{"zero offset", Cursor{}},
+	}
+	for _, tt := range tests {
+		var got Cursor
+		if err := got.UnmarshalText([]byte(tt.in.String())); err != nil {
+			t.Errorf("%s: UnmarshalText(%q) error = %v", tt.name, tt.in.String(), err)
+			continue
+		}
+		if got.IsOffset() != tt.in.IsOffset() ||
+			got.Offset != tt.in.Offset ||
+			got.ID != tt.in.ID ||
+			!got.Timestamp.Equal(tt.in.Timestamp.Time) {
+			t.Errorf("%s: round trip of %+v = %+v", tt.name, tt.in, got)
+		}
+	}
+}
+
+func TestCursorMalformed(t *testing.T) {
+	encode := func(s string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(s))
+	}
+	tests := []string{
+		"not base64!",
+		encode("abc"),
+		encode("-1"),
+		encode("x:01HQ0000000000000000000000"),
+		encode("1704067200:not-a-ulid"),
+	}
+	for _, in := range tests {
+		var c Cursor
+		if err := c.UnmarshalText([]byte(in)); err == nil {
+			t.Errorf("UnmarshalText(%q) = %+v, want error", in, c)
+		}
+	}
+}
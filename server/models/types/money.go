@@ -0,0 +1,116 @@
+package types
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// MinorUnits is the number of minor units (cents) in one major unit. It is
+// the same for every currency, as only those of two decimal places are
+// accepted.
+const MinorUnits = 100
+
+// Money is an exact amount of money in minor units (cents).
+type Money int64
+
+func ParseMoney(s string) (Money, error) {
+	s = strings.TrimSpace(s)
+	neg := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "-"), "+")
+	units, cents, _ := strings.Cut(s, ".")
+	if units == "" && cents == "" {
+		return 0, fmt.Errorf("money: invalid amount")
+	}
+	if len(cents) > 2 {
+		return 0, fmt.Errorf("money: too many decimal places")
+	}
+	cents += strings.Repeat("0", 2-len(cents))
+	if units == "" {
+		units = "0"
+	}
+	for _, c := range units + cents {
+		if c < '0' || c > '9' {
+			return 0, fmt.Errorf("money: invalid amount")
+		}
+	}
+	c, _ := strconv.ParseInt(cents, 10, 64)
+	u, err := strconv.ParseInt(units, 10, 64)
+	if err != nil || u > (math.MaxInt64-c)/MinorUnits {
+		return 0, fmt.Errorf("money: amount out of range")
+	}
+	m := Money(u*MinorUnits + c)
+	if neg {
+		m = -m
+	}
+	return m, nil
+}
+
+func (m Money) String() string {
+	sign, v := "", int64(m)
+	if v < 0 {
+		sign, v = "-", -v
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, v/MinorUnits, v%MinorUnits)
+}
+
+func (m Money) Value() (driver.Value, error) {
+	return int64(m), nil
+}
+
+func (m *Money) Scan(src any) error {
+	switch x := src.(type) {
+	case nil:
+		*m = 0
+		return nil
+	case int64:
+		*m = Money(x)
+		return nil
+	}
+	return fmt.Errorf("money: source value must be an integer")
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(m.String())
+}
+
+func (m *Money) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	v, err := ParseMoney(s)
+	*m = v
+	return err
+}
+
+func (m *Money) UnmarshalText(text []byte) (err error) {
+	*m, err = ParseMoney(string(text))
+	return
+}
+
+func (m *Money) UnmarshalGQL(v any) (err error) {
+	var s string
+	switch x := v.(type) {
+	case string:
+		s = x
+	case json.Number:
+		s = x.String()
+	case int64:
+		s = strconv.FormatInt(x, 10)
+	case float64:
+		s = strconv.FormatFloat(x, 'f', -1, 64)
+	default:
+		return fmt.Errorf("money: value must be a decimal string")
+	}
+	*m, err = ParseMoney(s)
+	return
+}
+
+func (m Money) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(m.String()))
+}
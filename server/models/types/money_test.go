@@ -0,0 +1,68 @@
+package types
+
+import "testing"
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Money
+		err  bool
+	}{
+		{"12.34", 1234, false},
+		{"-12.34", -1234, false},
+		{"+12.34", 1234, false},
+		{"12", 1200, false},
+		{"12.", 1200, false},
+		{"12.5", 1250, false},
+		{".5", 50, false},
+		{"-.05", -5, false},
+		{"-0.01", -1, false},
+		{"0", 0, false},
+		{" 7.10 ", 710, false},
+		{"92233720368547758.07", 9223372036854775807, false},
+
+		// amounts are exact, so extra decimal places are rejected
+		// rather than rounded
+		{"12.345", 0, true},
+		{"0.001", 0, true},
+		{"92233720368547758.08", 0, true},
+		{"", 0, true},
+		{"-", 0, true},
+		{".", 0, true},
+		{"--1", 0, true},
+		{"1,000", 0, true},
+		{"1e3", 0, true},
+		{"12.3a", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseMoney(tt.in)
+		if (err != nil) != tt.err {
+			t.Errorf("ParseMoney(%q) error = %v, want error %v", tt.in, err, tt.err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseMoney(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestMoneyString(t *testing.T) {
+	tests := []struct {
+		in   Money
+		want string
+	}{
+		{0, "0.00"},
+		{5, "0.05"},
+		{-5, "-0.05"},
+		{1234, "12.34"},
+		{-1200, "-12.00"},
+	}
+	for _, tt := range tests {
+		if got := tt.in.String(); got != tt.want {
+			t.Errorf("Money(%d).String() = %q, want %q", tt.in, got, tt.want)
+		}
+		if m, err := ParseMoney(tt.want); err != nil || m != tt.in {
+			t.Errorf("ParseMoney(%q) = %d, %v, want %d", tt.want, m, err, tt.in)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package models
+
+import "finawise.app/server/models/types"
+
+// DefaultWallet is the name of the wallet every group starts with.
+const DefaultWallet = "Main"
+
+// Wallet is where money of the group is kept, such as cash or a card. Its
+// balance starts at OpeningBalance, in the base currency of the group.
+type Wallet struct {
+	ID             types.ID         `db:"id" json:"id"`
+	GroupID        int64            `db:"group_id" json:"gid"`
+	Name           string           `db:"name" json:"name"`
+	OpeningBalance types.Money      `db:"opening_balance" json:"openingBalance"`
+	AsOf           *types.Timestamp `db:"-" json:"-"` // time of the balance, or now if nil
+}
+
+// Transfer moves money of an account from a wallet to another, which is
+// neither income nor expense.
+type Transfer struct {
+	ID           types.ID        `db:"id" json:"id"`
+	AccountID    int64           `db:"account_id" json:"aid"`
+	FromWalletID types.ID        `db:"from_wallet_id" json:"from"`
+	ToWalletID   types.ID        `db:"to_wallet_id" json:"to"`
+	Amount       types.Money     `db:"amount" json:"amount"`
+	Currency     string          `db:"currency" json:"currency"`
+	Title        string          `db:"title" json:"title"`
+	Timestamp    types.Timestamp `db:"timestamp" json:"timestamp"`
+}
@@ -0,0 +1,299 @@
+package repository
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/tnychn/sq"
+
+	"finawise.app/server/config"
+	"finawise.app/server/models"
+	"finawise.app/server/models/types"
+)
+
+// Migrations are named NNNN_name.sql and applied in order of their version
+// NNNN. A migration must never change once released; changes to the schema
+// are made by adding the next migration instead.
+//
+//go:embed "migrations/*.sql"
+var migrations embed.FS
+
+var ErrMigration = errors.New("invalid migrations")
+
+type Migration struct {
+	Version   int              `db:"version"`
+	Name      string           `db:"name"`
+	AppliedAt *types.Timestamp `db:"applied_at"` // nil if pending
+
+	sql string
+}
+
+const migrationsTable = `
+CREATE TABLE IF NOT EXISTS "schema_migrations" (
+    "version" INTEGER PRIMARY KEY,
+    "name" TEXT NOT NULL,
+    "applied_at" INTEGER NOT NULL
+);`
+
+// loadMigrations returns the embedded migrations ordered by version.
+func loadMigrations() (ms []Migration, err error) {
+	entries, err := fs.ReadDir(migrations, "migrations")
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		base, ok := strings.CutSuffix(e.Name(), ".sql")
+		version, name, found := strings.Cut(base, "_")
+		if !ok || !found || len(version) != 4 || name == "" {
+			return nil, fmt.Errorf("%w: bad file name %s", ErrMigration, e.Name())
+		}
+		m := Migration{Name: name}
+		if m.Version, err = strconv.Atoi(version); err != nil || m.Version < 1 {
+			return nil, fmt.Errorf("%w: bad version %s", ErrMigration, e.Name())
+		}
+		b, err := migrations.ReadFile(path.Join("migrations", e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		m.sql = string(b)
+		ms = append(ms, m)
+	}
+	slices.SortFunc(ms, func(a, b Migration) int { return a.Version - b.Version })
+	for i, m := range ms {
+		if m.Version != i+1 {
+			return nil, fmt.Errorf("%w: expected version %04d, got %04d", ErrMigration, i+1, m.Version)
+		}
+	}
+	return
+}
+
+// migrationFuncs complete the migrations of their version with what SQL alone
+// cannot do, such as making IDs, after the SQL of the migration.
+var migrationFuncs = map[int]func(tx *sqlx.Tx) error{
+	5: createDefaultWallets,
+}
+
+// migrationStatus returns every migration known to the server, with the time
+// it was applied to the database, if at all. It fails if the database has
+// migrations applied that the server does not know of, as the server is then
+// older than the schema.
+func migrationStatus(db *sqlx.DB) (ms []Migration, err error) {
+	if ms, err = loadMigrations(); err != nil {
+		return
+	}
+	if _, err = db.Exec(migrationsTable); err != nil {
+		return
+	}
+	var applied []Migration
+	if err = db.Select(&applied, `SELECT * FROM "schema_migrations" ORDER BY "version"`); err != nil {
+		return
+	}
+	for _, a := range applied {
+		if a.Version > len(ms) {
+			return nil, fmt.Errorf("%w: database has unknown migration %04d %s", ErrMigration, a.Version, a.Name)
+		}
+		ms[a.Version-1].AppliedAt = a.AppliedAt
+	}
+	return
+}
+
+// migrate applies the pending migrations in order, each within a transaction
+// of its own, and returns the migrations applied.
+func migrate(db *sqlx.DB) (applied []Migration, err error) {
+	ms, err := migrationStatus(db)
+	if err != nil {
+		return
+	}
+	for _, m := range ms {
+		if m.AppliedAt != nil {
+			continue
+		}
+		if err = applyMigration(db, &m); err != nil {
+			return applied, fmt.Errorf("migration %04d %s: %w", m.Version, m.Name, err)
+		}
+		applied = append(applied, m)
+	}
+	return
+}
+
+func applyMigration(db *sqlx.DB, m *Migration) error {
+	tx, err := db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// databases created before migrations existed are brought up to
+	// the initial schema, which creates only the tables that are missing
+	if m.Version == 1 {
+		if err := convertMoney(tx); err != nil {
+			return err
+		}
+		if err := addColumns(tx); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.Exec(m.sql); err != nil {
+		return err
+	}
+	if fn, ok := migrationFuncs[m.Version]; ok {
+		if err := fn(tx); err != nil {
+			return err
+		}
+	}
+
+	m.AppliedAt = &types.Timestamp{Time: time.Now()}
+	s, args := SQL.Insert("schema_migrations").
+		Columns("version", "name", "applied_at").
+		Values(m.Version, m.Name, m.AppliedAt).
+		MustSQL()
+	if _, err := tx.Exec(s, args...); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func open(config config.Config) (*sqlx.DB, error) {
+	return sqlx.Open("sqlite", config.Database.URL.String())
+}
+
+// MigrationStatus returns the migrations known to the server, without
+// applying those pending.
+func MigrationStatus(config config.Config) ([]Migration, error) {
+	db, err := open(config)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	return migrationStatus(db)
+}
+
+// Migrate applies the pending migrations and returns those applied.
+func Migrate(config config.Config) ([]Migration, error) {
+	db, err := open(config)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	return migrate(db)
+}
+
+// createDefaultWallets gives every group a default wallet, which takes the
+// transactions and recurrences of its members made before wallets existed.
+func createDefaultWallets(tx *sqlx.Tx) error {
+	var gids []int64
+	if err := tx.Select(&gids, `SELECT "id" FROM "groups"`); err != nil {
+		return err
+	}
+	for _, gid := range gids {
+		wid, err := createWallet(tx, models.Wallet{GroupID: gid, Name: models.DefaultWallet})
+		if err != nil {
+			return err
+		}
+		members := subquery.Select("id").
+			From("accounts").
+			Where(sq.Eq{"group_id": gid})
+		for _, table := range []string{"transactions", "recurrences"} {
+			s, args := SQL.Update(table).
+				Set("wallet_id", wid).
+				Where(sq.Eq{"wallet_id": nil}).
+				Where(sq.Expr("account_id IN (?)", members)).
+				MustSQL()
+			if _, err := tx.Exec(s, args...); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// The rest upgrades databases created before migrations existed, which were
+// kept up to date by the changes below. New changes belong in migrations.
+
+// moneyConversion rebuilds the tables that stored amounts as REAL, so that
+// amounts are stored as INTEGER minor units. Triggers dropped along with the
+// old tables are recreated by the initial migration afterwards.
+const moneyConversion = `
+CREATE TABLE "transactions_new" (
+    "id" TEXT PRIMARY KEY,
+    "account_id" INTEGER NOT NULL,
+    "category_id" TEXT NOT NULL,
+    "amount" INTEGER NOT NULL,
+    "title" TEXT NOT NULL,
+    "timestamp" INTEGER NOT NULL,
+    FOREIGN KEY ("account_id") REFERENCES "accounts"("id") ON DELETE CASCADE ON UPDATE CASCADE,
+    FOREIGN KEY ("category_id") REFERENCES "categories"("id") ON DELETE CASCADE ON UPDATE CASCADE
+);
+INSERT INTO "transactions_new" ("id", "account_id", "category_id", "amount", "title", "timestamp")
+SELECT "id", "account_id", "category_id", CAST(ROUND("amount" * 100) AS INTEGER), "title", "timestamp"
+FROM "transactions";
+DROP TABLE "transactions";
+ALTER TABLE "transactions_new" RENAME TO "transactions";
+
+CREATE TABLE "budgets_new" (
+    "category_id" TEXT PRIMARY KEY,
+    "amount" INTEGER NOT NULL CHECK ("amount" > 0),
+    FOREIGN KEY ("category_id") REFERENCES "categories"("id") ON DELETE CASCADE ON UPDATE CASCADE
+);
+INSERT INTO "budgets_new" ("category_id", "amount")
+SELECT "category_id", MAX(CAST(ROUND("amount" * 100) AS INTEGER), 1)
+FROM "budgets";
+DROP TABLE "budgets";
+ALTER TABLE "budgets_new" RENAME TO "budgets";
+`
+
+// columns added to tables after they were first created
+var columns = []struct{ table, column, definition string }{
+	{"groups", "currency", `TEXT NOT NULL DEFAULT 'HKD' CHECK ("currency" REGEXP '^[A-Z]{3}$')`},
+	{"transactions", "currency", `TEXT NOT NULL DEFAULT 'HKD' CHECK ("currency" REGEXP '^[A-Z]{3}$')`},
+	{"budgets", "period", `TEXT NOT NULL DEFAULT 'MONTHLY' CHECK ("period" IN ('WEEKLY', 'MONTHLY', 'QUARTERLY', 'YEARLY'))`},
+	{"budgets", "anchor", `INTEGER NOT NULL DEFAULT 0`},
+	{"transactions", "recurrence_id", `TEXT`},
+	{"budgets", "thresholds", `TEXT NOT NULL DEFAULT '80,100'`},
+	{"transactions", "external_id", `TEXT`},
+}
+
+func addColumns(tx *sqlx.Tx) error {
+	for _, c := range columns {
+		var n int
+		err := tx.Get(&n, `SELECT COUNT(*) FROM pragma_table_info(?)`, c.table)
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			continue // table is yet to be created by the initial migration
+		}
+		err = tx.Get(&n, `SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?`, c.table, c.column)
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			s := fmt.Sprintf(`ALTER TABLE "%s" ADD COLUMN "%s" %s`, c.table, c.column, c.definition)
+			if _, err := tx.Exec(s); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func convertMoney(tx *sqlx.Tx) error {
+	var t string
+	err := tx.Get(&t, `SELECT type FROM pragma_table_info('transactions') WHERE name = 'amount'`)
+	if err == ErrNoRows || (err == nil && t != "REAL") {
+		return nil // fresh database or already converted
+	}
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(moneyConversion)
+	return err
+}
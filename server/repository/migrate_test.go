@@ -0,0 +1,175 @@
+package repository
+
+import (
+	_ "embed"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+)
+
+//go:embed "testdata/baseline.sql"
+var baseline string
+
+func openTest(t *testing.T) *sqlx.DB {
+	t.Helper()
+	db, err := sqlx.Open("sqlite", filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestLoadMigrations(t *testing.T) {
+	ms, err := loadMigrations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ms) == 0 {
+		t.Fatal("no migrations")
+	}
+	for i, m := range ms {
+		if m.Version != i+1 || m.Name == "" || m.sql == "" || m.AppliedAt != nil {
+			t.Errorf("migration %d = %04d %q", i, m.Version, m.Name)
+		}
+	}
+	for v := range migrationFuncs {
+		if v < 1 || v > len(ms) {
+			t.Errorf("migration func of unknown version %04d", v)
+		}
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+	}{
+		{"fresh", ""},
+		{"baseline", baseline},
+	}
+	for _, tt := range tests {
+		db := openTest(t)
+		if _, err := db.Exec(tt.schema); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		ms, err := loadMigrations()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		applied, err := migrate(db)
+		if err != nil {
+			t.Fatalf("%s: migrate error = %v", tt.name, err)
+		}
+		if len(applied) != len(ms) {
+			t.Errorf("%s: applied %d migrations, want %d", tt.name, len(applied), len(ms))
+		}
+		if applied, err = migrate(db); err != nil || len(applied) != 0 {
+			t.Errorf("%s: migrate again = %d applied, %v, want none", tt.name, len(applied), err)
+		}
+		status, err := migrationStatus(db)
+		if err != nil {
+			t.Fatalf("%s: migrationStatus error = %v", tt.name, err)
+		}
+		for _, m := range status {
+			if m.AppliedAt == nil {
+				t.Errorf("%s: migration %04d %s is pending", tt.name, m.Version, m.Name)
+			}
+		}
+	}
+}
+
+// TestMigrateBaselineData migrates the data of a database created before
+// migrations existed.
+func TestMigrateBaselineData(t *testing.T) {
+	db := openTest(t)
+	if _, err := db.Exec(baseline); err != nil {
+		t.Fatal(err)
+	}
+	_, err := db.Exec(`
+INSERT INTO "groups" ("id") VALUES (1), (2);
+INSERT INTO "accounts" ("id", "group_id", "email", "fullname", "passhash") VALUES
+    (1, 1, 'a@example.com', 'A', ''),
+    (2, 1, 'b@example.com', 'B', ''),
+    (3, 2, 'c@example.com', 'C', '');
+INSERT INTO "categories" ("id", "group_id", "name", "type", "emoji", "color") VALUES
+    ('01HQ00000000000000000000C1', 1, 'Food', 'EXPENSE', 'x', '#FFFFFF'),
+    ('01HQ00000000000000000000C2', 2, 'Pay', 'INCOME', 'x', '#FFFFFF');
+INSERT INTO "transactions" ("id", "account_id", "category_id", "amount", "title", "timestamp") VALUES
+    ('01HQ00000000000000000000T1', 1, '01HQ00000000000000000000C1', 12.34, 'Coffee', 1704067200),
+    ('01HQ00000000000000000000T2', 2, '01HQ00000000000000000000C1', 0.1, 'Gum', 1704067200),
+    ('01HQ00000000000000000000T3', 3, '01HQ00000000000000000000C2', 1000, 'Salary', 1704067200);
+INSERT INTO "budgets" ("category_id", "amount") VALUES ('01HQ00000000000000000000C1', 0.001);
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrate(db); err != nil {
+		t.Fatalf("migrate error = %v", err)
+	}
+
+	var amounts []int64
+	if err := db.Select(&amounts, `SELECT "amount" FROM "transactions" ORDER BY "id"`); err != nil {
+		t.Fatal(err)
+	}
+	if want := []int64{1234, 10, 100000}; len(amounts) != 3 || amounts[0] != want[0] || amounts[1] != want[1] || amounts[2] != want[2] {
+		t.Errorf("amounts = %v, want %v", amounts, want)
+	}
+	var budget int64
+	if err := db.Get(&budget, `SELECT "amount" FROM "budgets"`); err != nil || budget != 1 {
+		t.Errorf("budget = %d, %v, want 1", budget, err)
+	}
+
+	var roles []string
+	if err := db.Select(&roles, `SELECT DISTINCT "role" FROM "accounts"`); err != nil || len(roles) != 1 || roles[0] != "OWNER" {
+		t.Errorf("roles = %v, %v, want [OWNER]", roles, err)
+	}
+
+	// every group has a default wallet with the transactions of its members
+	var wallets []struct {
+		GroupID int64  `db:"group_id"`
+		Name    string `db:"name"`
+		Count   int    `db:"count"`
+	}
+	err = db.Select(&wallets, `
+SELECT w."group_id", w."name", COUNT(t."id") AS "count"
+FROM "wallets" w LEFT JOIN "transactions" t ON t."wallet_id" = w."id"
+GROUP BY w."id" ORDER BY w."group_id"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(wallets) != 2 || wallets[0].Count != 2 || wallets[1].Count != 1 ||
+		wallets[0].Name != "Main" || wallets[1].Name != "Main" {
+		t.Errorf("wallets = %+v, want one Main wallet per group", wallets)
+	}
+	var orphans int
+	if err := db.Get(&orphans, `SELECT COUNT(*) FROM "transactions" WHERE "wallet_id" IS NULL`); err != nil || orphans != 0 {
+		t.Errorf("transactions without wallet = %d, %v", orphans, err)
+	}
+
+	// existing transactions are searchable and listed as entries
+	var found, entries int
+	if err := db.Get(&found, `SELECT COUNT(*) FROM "transactions_fts" WHERE "transactions_fts" MATCH 'coffee'`); err != nil || found != 1 {
+		t.Errorf("search for coffee = %d, %v, want 1", found, err)
+	}
+	if err := db.Get(&entries, `SELECT COUNT(*) FROM "entries"`); err != nil || entries != 3 {
+		t.Errorf("entries = %d, %v, want 3", entries, err)
+	}
+}
+
+func TestMigrationStatusUnknown(t *testing.T) {
+	db := openTest(t)
+	if _, err := migrate(db); err != nil {
+		t.Fatal(err)
+	}
+	_, err := db.Exec(`INSERT INTO "schema_migrations" ("version", "name", "applied_at") VALUES (9999, 'future', 0)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrationStatus(db); !errors.Is(err, ErrMigration) {
+		t.Errorf("migrationStatus error = %v, want %v", err, ErrMigration)
+	}
+}
@@ -0,0 +1,155 @@
+-- The initial schema. Tables are created only if missing, so that databases
+-- created before migrations existed are adopted as they are.
+
+CREATE TABLE IF NOT EXISTS "licensekeys" (
+    "key" TEXT PRIMARY KEY
+);
+
+CREATE TABLE IF NOT EXISTS "accounts" (
+    "id" INTEGER PRIMARY KEY AUTOINCREMENT,
+    "group_id" INTEGER NOT NULL,
+    "email" TEXT NOT NULL UNIQUE,
+    "fullname" TEXT NOT NULL,
+    "passhash" TEXT NOT NULL,
+    FOREIGN KEY ("group_id") REFERENCES "groups"("id") ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS "groups" (
+    "id" INTEGER PRIMARY KEY AUTOINCREMENT,
+    "currency" TEXT NOT NULL DEFAULT 'HKD' CHECK ("currency" REGEXP '^[A-Z]{3}$') -- base currency
+);
+
+CREATE TABLE IF NOT EXISTS "categories" (
+    "id" TEXT PRIMARY KEY,
+    "group_id" INTEGER NOT NULL,
+    "name" TEXT NOT NULL,
+    "type" TEXT NOT NULL CHECK ("type" IN ("INCOME", "EXPENSE")),
+    "emoji" TEXT NOT NULL CHECK (LENGTH("emoji") >= 1 AND LENGTH("emoji") <= 4),
+    "color" TEXT NOT NULL CHECK ("color" REGEXP '#[0-9A-F]{6}'),
+    UNIQUE ("group_id", "name", "type"),
+    FOREIGN KEY ("group_id") REFERENCES "groups"("id") ON DELETE CASCADE ON UPDATE CASCADE
+    FOREIGN KEY ("type") REFERENCES "TYPE"("name") ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS "transactions" (
+    "id" TEXT PRIMARY KEY,
+    "account_id" INTEGER NOT NULL,
+    "category_id" TEXT NOT NULL,
+    "amount" INTEGER NOT NULL, -- in minor units
+    "title" TEXT NOT NULL,
+    "timestamp" INTEGER NOT NULL,
+    "currency" TEXT NOT NULL DEFAULT 'HKD' CHECK ("currency" REGEXP '^[A-Z]{3}$'),
+    "recurrence_id" TEXT, -- set if created by a recurrence
+    "external_id" TEXT, -- FITID of the bank, set if imported from a statement
+    FOREIGN KEY ("account_id") REFERENCES "accounts"("id") ON DELETE CASCADE ON UPDATE CASCADE,
+    FOREIGN KEY ("category_id") REFERENCES "categories"("id") ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+-- each occurrence of a recurrence is created at most once
+CREATE UNIQUE INDEX IF NOT EXISTS "transactions_recurrence"
+ON "transactions" ("recurrence_id", "timestamp") WHERE "recurrence_id" IS NOT NULL;
+
+-- imports look up duplicates by account, amount and title
+CREATE INDEX IF NOT EXISTS "transactions_duplicate" ON "transactions" ("account_id", "amount", "title");
+
+-- each transaction of a bank is imported at most once, even from overlapping statements
+CREATE UNIQUE INDEX IF NOT EXISTS "transactions_external"
+ON "transactions" ("account_id", "external_id") WHERE "external_id" IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS "recurrences" (
+    "id" TEXT PRIMARY KEY,
+    "account_id" INTEGER NOT NULL,
+    "category_id" TEXT NOT NULL,
+    "title" TEXT NOT NULL,
+    "amount" INTEGER NOT NULL CHECK ("amount" > 0), -- in minor units
+    "currency" TEXT NOT NULL CHECK ("currency" REGEXP '^[A-Z]{3}$'),
+    "frequency" TEXT NOT NULL CHECK ("frequency" IN ('DAILY', 'WEEKLY', 'MONTHLY', 'YEARLY')),
+    "interval" INTEGER NOT NULL CHECK ("interval" >= 1),
+    "start" INTEGER NOT NULL,
+    "until" INTEGER,
+    "count" INTEGER CHECK ("count" >= 1),
+    "next" INTEGER NOT NULL DEFAULT 0, -- index of the next occurrence to create
+    "next_at" INTEGER, -- time of the next occurrence, NULL once ended
+    FOREIGN KEY ("account_id") REFERENCES "accounts"("id") ON DELETE CASCADE ON UPDATE CASCADE,
+    FOREIGN KEY ("category_id") REFERENCES "categories"("id") ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS "recurrences_next_at" ON "recurrences" ("next_at");
+
+-- full-text index over the searchable columns of transactions
+CREATE VIRTUAL TABLE IF NOT EXISTS "transactions_fts" USING fts5(
+    "id" UNINDEXED,
+    "title",
+    prefix = '2 3'
+);
+
+CREATE TRIGGER IF NOT EXISTS transactions_fts_insert
+AFTER INSERT ON "transactions"
+BEGIN
+    INSERT INTO "transactions_fts" ("id", "title") VALUES (NEW."id", NEW."title");
+END;
+
+CREATE TRIGGER IF NOT EXISTS transactions_fts_update
+AFTER UPDATE OF "id", "title" ON "transactions"
+BEGIN
+    UPDATE "transactions_fts" SET "id" = NEW."id", "title" = NEW."title" WHERE "id" = OLD."id";
+END;
+
+CREATE TRIGGER IF NOT EXISTS transactions_fts_delete
+AFTER DELETE ON "transactions"
+BEGIN
+    DELETE FROM "transactions_fts" WHERE "id" = OLD."id";
+END;
+
+-- index transactions created before the full-text index existed
+INSERT INTO "transactions_fts" ("id", "title")
+SELECT "id", "title" FROM "transactions"
+WHERE NOT EXISTS (SELECT 1 FROM "transactions_fts");
+
+CREATE TABLE IF NOT EXISTS "exchange_rates" (
+    "date" TEXT NOT NULL, -- YYYY-MM-DD
+    "from" TEXT NOT NULL CHECK ("from" REGEXP '^[A-Z]{3}$'),
+    "to" TEXT NOT NULL CHECK ("to" REGEXP '^[A-Z]{3}$'),
+    "rate" REAL NOT NULL CHECK ("rate" > 0), -- units of "to" per unit of "from"
+    PRIMARY KEY ("from", "to", "date")
+);
+
+CREATE TABLE IF NOT EXISTS "budgets" (
+    "category_id" TEXT PRIMARY KEY,
+    "amount" INTEGER NOT NULL CHECK ("amount" > 0), -- in minor units
+    "period" TEXT NOT NULL DEFAULT 'MONTHLY' CHECK ("period" IN ('WEEKLY', 'MONTHLY', 'QUARTERLY', 'YEARLY')),
+    "anchor" INTEGER NOT NULL DEFAULT 0, -- timestamp from which periods repeat
+    "thresholds" TEXT NOT NULL DEFAULT '80,100', -- percentages of amount to alert at
+    FOREIGN KEY ("category_id") REFERENCES "categories"("id") ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE TRIGGER IF NOT EXISTS check_budget_category
+BEFORE INSERT ON "budgets"
+BEGIN
+    SELECT RAISE(FAIL, "budget cannot be set for income category")
+    FROM "categories" WHERE "id" = NEW."category_id" AND "type" = 'INCOME';
+END;
+
+CREATE TRIGGER IF NOT EXISTS check_budget_category_update
+BEFORE UPDATE ON "budgets"
+BEGIN
+    SELECT RAISE(FAIL, "budget cannot be set for income category")
+    FROM "categories" WHERE "id" = NEW."category_id" AND "type" = 'INCOME';
+END;
+
+CREATE TABLE IF NOT EXISTS "notifications" (
+    "id" TEXT PRIMARY KEY,
+    "group_id" INTEGER NOT NULL,
+    "category_id" TEXT NOT NULL,
+    "threshold" INTEGER NOT NULL, -- percentage of amount
+    "period_start" INTEGER NOT NULL,
+    "amount" INTEGER NOT NULL, -- in minor units
+    "spent" INTEGER NOT NULL, -- in minor units
+    "timestamp" INTEGER NOT NULL,
+    "read" INTEGER NOT NULL DEFAULT 0,
+    FOREIGN KEY ("group_id") REFERENCES "groups"("id") ON DELETE CASCADE ON UPDATE CASCADE,
+    FOREIGN KEY ("category_id") REFERENCES "categories"("id") ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+-- alert at most once for each threshold in each period
+CREATE UNIQUE INDEX IF NOT EXISTS notifications_threshold ON "notifications" ("category_id", "threshold", "period_start");
@@ -0,0 +1,12 @@
+-- accounts created before groups could be shared own their group
+ALTER TABLE "accounts" ADD COLUMN "role" TEXT NOT NULL DEFAULT 'OWNER' CHECK ("role" IN ('OWNER', 'MEMBER'));
+
+CREATE TABLE "invitations" (
+    "code" TEXT PRIMARY KEY,
+    "group_id" INTEGER NOT NULL,
+    "created_by" INTEGER NOT NULL,
+    "created_at" INTEGER NOT NULL,
+    "expires_at" INTEGER NOT NULL,
+    FOREIGN KEY ("group_id") REFERENCES "groups"("id") ON DELETE CASCADE ON UPDATE CASCADE,
+    FOREIGN KEY ("created_by") REFERENCES "accounts"("id") ON DELETE CASCADE ON UPDATE CASCADE
+);
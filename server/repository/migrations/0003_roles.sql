@@ -0,0 +1,18 @@
+-- members become editors, next to the new viewers; the check on the role
+-- can only change by rebuilding the table
+CREATE TABLE "accounts_new" (
+    "id" INTEGER PRIMARY KEY AUTOINCREMENT,
+    "group_id" INTEGER NOT NULL,
+    "email" TEXT NOT NULL UNIQUE,
+    "fullname" TEXT NOT NULL,
+    "passhash" TEXT NOT NULL,
+    "role" TEXT NOT NULL DEFAULT 'OWNER' CHECK ("role" IN ('OWNER', 'EDITOR', 'VIEWER')),
+    FOREIGN KEY ("group_id") REFERENCES "groups"("id") ON DELETE CASCADE ON UPDATE CASCADE
+);
+INSERT INTO "accounts_new" ("id", "group_id", "email", "fullname", "passhash", "role")
+SELECT "id", "group_id", "email", "fullname", "passhash", CASE "role" WHEN 'MEMBER' THEN 'EDITOR' ELSE "role" END
+FROM "accounts";
+DROP TABLE "accounts";
+ALTER TABLE "accounts_new" RENAME TO "accounts";
+
+ALTER TABLE "invitations" ADD COLUMN "role" TEXT NOT NULL DEFAULT 'EDITOR' CHECK ("role" IN ('OWNER', 'EDITOR', 'VIEWER'));
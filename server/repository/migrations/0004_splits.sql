@@ -0,0 +1,26 @@
+-- lines of a transaction split across categories, which add up to its amount
+CREATE TABLE "splits" (
+    "id" TEXT PRIMARY KEY,
+    "transaction_id" TEXT NOT NULL,
+    "category_id" TEXT NOT NULL,
+    "amount" INTEGER NOT NULL CHECK ("amount" > 0), -- in minor units
+    FOREIGN KEY ("transaction_id") REFERENCES "transactions"("id") ON DELETE CASCADE ON UPDATE CASCADE,
+    FOREIGN KEY ("category_id") REFERENCES "categories"("id") ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE INDEX "splits_transaction" ON "splits" ("transaction_id");
+CREATE INDEX "splits_category" ON "splits" ("category_id");
+
+-- what totals count: the splits of split transactions, and the other
+-- transactions as they are; splits take the rest of their columns from the
+-- transaction
+CREATE VIEW "entries" AS
+SELECT t."id", t."account_id", t."category_id", t."amount", t."title", t."timestamp",
+    t."currency", t."recurrence_id", t."external_id"
+FROM "transactions" t
+WHERE NOT EXISTS (SELECT 1 FROM "splits" s WHERE s."transaction_id" = t."id")
+UNION ALL
+SELECT t."id", t."account_id", s."category_id", s."amount", t."title", t."timestamp",
+    t."currency", t."recurrence_id", t."external_id"
+FROM "transactions" t
+JOIN "splits" s ON s."transaction_id" = t."id";
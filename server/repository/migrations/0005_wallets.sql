@@ -0,0 +1,48 @@
+-- where the money of a group is kept, such as cash, cards or savings
+CREATE TABLE "wallets" (
+    "id" TEXT PRIMARY KEY,
+    "group_id" INTEGER NOT NULL,
+    "name" TEXT NOT NULL,
+    "opening_balance" INTEGER NOT NULL DEFAULT 0, -- in minor units of the base currency
+    UNIQUE ("group_id", "name"),
+    FOREIGN KEY ("group_id") REFERENCES "groups"("id") ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+-- set for every transaction and recurrence, existing ones are given the
+-- default wallet of their group after this migration
+ALTER TABLE "transactions" ADD COLUMN "wallet_id" TEXT REFERENCES "wallets"("id");
+ALTER TABLE "recurrences" ADD COLUMN "wallet_id" TEXT REFERENCES "wallets"("id");
+
+CREATE INDEX "transactions_wallet" ON "transactions" ("wallet_id");
+
+-- money moved between wallets, which is neither income nor expense
+CREATE TABLE "transfers" (
+    "id" TEXT PRIMARY KEY,
+    "account_id" INTEGER NOT NULL,
+    "from_wallet_id" TEXT NOT NULL,
+    "to_wallet_id" TEXT NOT NULL,
+    "amount" INTEGER NOT NULL CHECK ("amount" > 0), -- in minor units
+    "currency" TEXT NOT NULL CHECK ("currency" REGEXP '^[A-Z]{3}$'),
+    "title" TEXT NOT NULL DEFAULT '',
+    "timestamp" INTEGER NOT NULL,
+    CHECK ("from_wallet_id" <> "to_wallet_id"),
+    FOREIGN KEY ("account_id") REFERENCES "accounts"("id") ON DELETE CASCADE ON UPDATE CASCADE,
+    FOREIGN KEY ("from_wallet_id") REFERENCES "wallets"("id") ON DELETE CASCADE ON UPDATE CASCADE,
+    FOREIGN KEY ("to_wallet_id") REFERENCES "wallets"("id") ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE INDEX "transfers_from" ON "transfers" ("from_wallet_id");
+CREATE INDEX "transfers_to" ON "transfers" ("to_wallet_id");
+
+-- entries carry the wallet too, so that they can be filtered by it
+DROP VIEW "entries";
+CREATE VIEW "entries" AS
+SELECT t."id", t."account_id", t."category_id", t."amount", t."title", t."timestamp",
+    t."currency", t."recurrence_id", t."external_id", t."wallet_id"
+FROM "transactions" t
+WHERE NOT EXISTS (SELECT 1 FROM "splits" s WHERE s."transaction_id" = t."id")
+UNION ALL
+SELECT t."id", t."account_id", s."category_id", s."amount", t."title", t."timestamp",
+    t."currency", t."recurrence_id", t."external_id", t."wallet_id"
+FROM "transactions" t
+JOIN "splits" s ON s."transaction_id" = t."id";
@@ -0,0 +1,18 @@
+-- free-form labels of a group, which mark transactions across categories
+CREATE TABLE "tags" (
+    "id" TEXT PRIMARY KEY,
+    "group_id" INTEGER NOT NULL,
+    "name" TEXT NOT NULL COLLATE NOCASE, -- without the leading #
+    UNIQUE ("group_id", "name"),
+    FOREIGN KEY ("group_id") REFERENCES "groups"("id") ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE TABLE "transaction_tags" (
+    "transaction_id" TEXT NOT NULL,
+    "tag_id" TEXT NOT NULL,
+    PRIMARY KEY ("transaction_id", "tag_id"),
+    FOREIGN KEY ("transaction_id") REFERENCES "transactions"("id") ON DELETE CASCADE ON UPDATE CASCADE,
+    FOREIGN KEY ("tag_id") REFERENCES "tags"("id") ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE INDEX "transaction_tags_tag" ON "transaction_tags" ("tag_id");
@@ -3,11 +3,17 @@ package repository
 import (
 	"database/sql"
 	"database/sql/driver"
-	_ "embed"
+	"errors"
 	"fmt"
 	"regexp"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/rs/zerolog/log"
 	"github.com/tnychn/sq"
 	"modernc.org/sqlite"
 
@@ -41,12 +47,38 @@ func init() {
 	})
 }
 
-//go:embed "schema.sql"
-var schema string
-
 var SQL = sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
 
-var ErrNoRows = sql.ErrNoRows
+// subquery builds queries nested in others with sq.Expr, which leaves their
+// placeholders to be numbered along with those of the outer query.
+var subquery = sq.StatementBuilder
+
+var (
+	ErrNoRows       = sql.ErrNoRows
+	ErrCategoryType = errors.New("categories must be of the same type")
+	ErrCategorySelf = errors.New("category cannot be merged into itself")
+	ErrCategoryUsed = errors.New("category still has transactions or recurrences, which must be reassigned")
+	ErrBudgetPeriod = errors.New("budgets of different periods cannot be merged")
+	ErrPage         = errors.New("invalid pagination arguments")
+	ErrExchangeRate = errors.New("no exchange rate")
+	ErrInterval     = errors.New("too many intervals in range")
+	ErrArchive      = errors.New("archive refers to unknown entity")
+	ErrNotEmpty     = errors.New("account already has transactions")
+	ErrInvitation   = errors.New("invalid or expired invitation")
+	ErrMember       = errors.New("account is already a member of the group")
+	ErrAlone        = errors.New("account is the only member of its group")
+	ErrLastOwner    = errors.New("group must keep at least one owner")
+	ErrForbidden    = errors.New("role in the group does not allow this")
+	ErrNoCategory   = errors.New("transaction needs either a category or splits")
+	ErrSplitCount   = errors.New("transaction must be split into 2 to 20 splits")
+	ErrSplitSum     = errors.New("amounts of splits must add up to the amount of the transaction")
+	ErrSplitChange  = errors.New("category and amount of a split transaction follow its splits")
+	ErrWalletSelf   = errors.New("wallet cannot be reassigned to itself")
+	ErrWalletLast   = errors.New("group must keep at least one wallet")
+	ErrWalletUsed   = errors.New("wallet still has transactions or transfers")
+	ErrTransferSelf = errors.New("transfer must be between different wallets")
+	ErrTagName      = errors.New("tag names must have 1 to 30 characters without spaces")
+)
 
 type Error = sqlite.Error
 
@@ -54,33 +86,133 @@ type Repository interface {
 	container.Initializable
 	container.Terminatable
 
+	Ready() <-chan struct{}
+
 	CreateCategory(c models.Category) (types.ID, error)
-	CreateTransaction(t models.Transaction) (types.ID, error)
-	CreateBudget(b models.Budget) error
+	CreateTransaction(gid int64, t models.Transaction, splits []models.Split) (types.ID, error)
+	CreateBudget(gid int64, b models.Budget) error
+	ImportTransactions(gid int64, cs []models.Category, txns []models.Transaction, dryRun bool) (duplicates []bool, err error)
+	GetPayeeCategory(aid int64, title string, ct models.CategoryType) (types.ID, error)
 
-	GetCategory(cid types.ID) (models.Category, error)
+	UpdateCategory(c models.Category) error
+	DeleteCategory(gid int64, cid types.ID, reassign *types.ID) error
+	MergeCategories(gid int64, sources []types.ID, target types.ID) error
+	UpdateTransaction(gid int64, t models.Transaction) error
+	SetSplits(gid int64, t models.Transaction, splits []models.Split) (models.Transaction, error)
+	ListSplits(aid int64, tid types.ID) ([]models.Split, error)
+	SetBudget(gid int64, b models.Budget) error
+	DeleteTransaction(aid int64, tid types.ID) error
+	RemoveBudget(gid int64, cid types.ID) error
+
+	GetCategory(gid int64, cid types.ID) (models.Category, error)
 	GetCategories(gid int64, ct *models.CategoryType) ([]models.Category, error)
-	GetTransaction(tid types.ID) (models.Transaction, error)
-	GetBudget(cid types.ID) (models.Budget, error)
+	GetTransaction(aid int64, tid types.ID) (models.Transaction, error)
+	GetBudget(gid int64, cid types.ID) (models.Budget, error)
 	GetBudgets(gid int64) ([]models.Budget, error)
+	GetSpending(gid int64, cid types.ID, from, to types.Timestamp) (types.Money, error)
 	GetAccount(aid int64) (models.Account, error)
-	GetAccountSummary(aid int64) (as models.AccountSummary, err error)
+	GetGroup(gid int64) (models.Group, error)
+	GetAccountSummary(aid int64, from, to *types.Timestamp) (models.AccountSummary, error)
+	GetCategorySummaries(aid int64, from, to *types.Timestamp) ([]models.CategorySummary, error)
+	GetTagSummaries(aid int64, from, to *types.Timestamp) ([]models.TagSummary, error)
+	GetTimeSeries(aid int64, from, to types.Timestamp, i models.Interval, g models.TimeSeriesGroup) ([]models.TimeSeries, error)
+
+	UpdateGroup(g models.Group) error
+	ImportExchangeRates(rates []models.ExchangeRate) error
 
 	CreateAccount(a models.Account, key string) (int64, error)
 	FindAccountByEmail(email string) (models.Account, error)
+	DeleteAccount(aid int64) error
+	CreateMember(a models.Account, code string, now types.Timestamp) (int64, error)
+	ListMembers(gid int64) ([]models.Account, error)
+	RemoveMember(gid, owner, aid int64) error
+	SetRole(gid, owner, aid int64, role models.Role) error
+	LeaveGroup(aid int64) (gid int64, err error)
+
+	CreateInvitation(inv models.Invitation) error
+	ListInvitations(gid int64, now types.Timestamp) ([]models.Invitation, error)
+	DeleteInvitation(gid int64, code string) error
+	AcceptInvitation(aid int64, code string, now types.Timestamp) (gid int64, err error)
+
+	ExportGroup(gid int64) (models.Archive, error)
+	ImportGroup(aid int64, a models.Archive) (gid int64, err error)
 
-	// TODO: implement pagination
-	ListTransactions(aid int64, cid *types.ID, ct *models.CategoryType) ([]models.Transaction, error)
+	CreateRecurrence(gid int64, rec models.Recurrence) (types.ID, error)
+	GetRecurrence(aid int64, rid types.ID) (models.Recurrence, error)
+	ListRecurrences(aid int64) ([]models.Recurrence, error)
+	DeleteRecurrence(aid int64, rid types.ID) error
+	ListDueRecurrences(now types.Timestamp) ([]models.Recurrence, error)
+	CreateOccurrences(rec models.Recurrence, txns []models.Transaction) (created []models.Transaction, err error)
+
+	CreateWallet(w models.Wallet) (types.ID, error)
+	UpdateWallet(w models.Wallet) error
+	DeleteWallet(gid int64, wid types.ID, reassign *types.ID) error
+	GetWallet(gid int64, wid types.ID) (models.Wallet, error)
+	ListWallets(gid int64) ([]models.Wallet, error)
+	GetBalance(gid int64, wid types.ID, asOf types.Timestamp) (types.Money, error)
+
+	CreateTransfer(gid int64, t models.Transfer) (types.ID, error)
+	ListTransfers(aid int64, wid *types.ID) ([]models.Transfer, error)
+	DeleteTransfer(aid int64, id types.ID) error
+
+	ListTags(gid int64) ([]models.Tag, error)
+	GetTag(gid int64, id types.ID) (models.Tag, error)
+	DeleteTag(gid int64, id types.ID) error
+	ListTransactionTags(aid int64, tid types.ID) ([]models.Tag, error)
+	TagTransaction(gid, aid int64, tid types.ID, names []string) error
+	UntagTransaction(gid, aid int64, tid types.ID, names []string) error
+
+	CreateNotifications(ns []models.Notification) error
+	ListNotifications(gid int64, unreadOnly bool) ([]models.Notification, error)
+	MarkNotificationsRead(gid int64, ids []types.ID) (int, error)
+
+	ListTransactions(aid int64, cid *types.ID, f models.TransactionFilter, p models.Page) (models.TransactionConnection, error)
+	SearchTransactions(aid int64, text string, p models.Page) (models.SearchConnection, error)
+	ExportTransactions(aid int64, f models.TransactionFilter, fn func(models.TransactionExport) error) error
 }
 
 type repository struct {
 	config config.Config
 
-	db *sqlx.DB
+	db    *sqlx.DB
+	ready chan struct{}
+}
+
+// affected reports ErrNoRows when a write statement matched no rows.
+func affected(result sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNoRows
+	}
+	return nil
+}
+
+// checkCategory reports ErrNoRows unless the category belongs to the group.
+func checkCategory(q sqlx.Queryer, gid int64, cid types.ID) error {
+	s, args := SQL.Select("1").
+		From("categories").
+		Where(sq.Eq{"id": cid, "group_id": gid}).
+		MustSQL()
+	return sqlx.Get(q, new(int), s, args...)
+}
+
+// checkWallet reports ErrNoRows unless the wallet belongs to the group.
+func checkWallet(q sqlx.Queryer, gid int64, wid types.ID) error {
+	s, args := SQL.Select("1").
+		From("wallets").
+		Where(sq.Eq{"id": wid, "group_id": gid}).
+		MustSQL()
+	return sqlx.Get(q, new(int), s, args...)
 }
 
 func New(config config.Config) Repository {
-	return &repository{config: config}
+	return &repository{config: config, ready: make(chan struct{})}
 }
 
 func (r *repository) Initialize() (err error) {
@@ -89,10 +221,22 @@ func (r *repository) Initialize() (err error) {
 	if err != nil {
 		return
 	}
-	_, err = r.db.Exec(schema)
+	applied, err := migrate(r.db)
+	for _, m := range applied {
+		log.Info().Msgf("applied migration %04d %s", m.Version, m.Name)
+	}
+	if err == nil {
+		close(r.ready)
+	}
 	return
 }
 
+// Ready is closed once the repository has been initialized, for services
+// initialized alongside it that use it on their own.
+func (r *repository) Ready() <-chan struct{} {
+	return r.ready
+}
+
 func (r *repository) Terminate() (err error) {
 	if r.db != nil {
 		err = r.db.Close()
@@ -110,29 +254,407 @@ func (r *repository) CreateCategory(c models.Category) (types.ID, error) {
 	return cid, err
 }
 
-func (r *repository) CreateTransaction(t models.Transaction) (types.ID, error) {
-	tid := types.MakeID()
+// CreateTransaction creates the transaction, split into the splits if any,
+// in which case it takes the category of its largest split.
+func (r *repository) CreateTransaction(gid int64, t models.Transaction, splits []models.Split) (types.ID, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return types.ZeroID, err
+	}
+	defer tx.Rollback()
+
+	if len(splits) > 0 {
+		t.CategoryID = splits[0].CategoryID // until set by the splits
+	} else if t.CategoryID.IsZero() {
+		return types.ZeroID, ErrNoCategory
+	}
+	if err := checkCategory(tx, gid, t.CategoryID); err != nil {
+		return types.ZeroID, err
+	}
+	if err := checkWallet(tx, gid, t.WalletID); err != nil {
+		return types.ZeroID, err
+	}
+	t.ID = types.MakeID()
 	s, args := SQL.Insert("transactions").
-		Columns("id", "account_id", "category_id", "amount", "timestamp", "title").
-		Values(tid, t.AccountID, t.CategoryID, t.Amount, t.Timestamp, t.Title).
+		Columns("id", "account_id", "category_id", "wallet_id", "amount", "timestamp", "title", "currency").
+		Values(t.ID, t.AccountID, t.CategoryID, t.WalletID, t.Amount, t.Timestamp, t.Title, t.Currency).
 		MustSQL()
-	_, err := r.db.Exec(s, args...)
-	return tid, err
+	if _, err := tx.Exec(s, args...); err != nil {
+		return types.ZeroID, err
+	}
+	if err := setSplits(tx, gid, &t, splits); err != nil {
+		return types.ZeroID, err
+	}
+
+	return t.ID, tx.Commit()
 }
 
-func (r *repository) CreateBudget(b models.Budget) error {
+func (r *repository) CreateBudget(gid int64, b models.Budget) error {
+	// select from categories so that budgets are only created within the group
 	s, args := SQL.Insert("budgets").
-		Columns("category_id", "amount").
-		Values(b.CategoryID, b.Amount).
+		Columns("category_id", "amount", "period", "anchor", "thresholds").
+		Select(SQL.Select("id").
+			Column(sq.Expr("?", b.Amount)).
+			Column(sq.Expr("?", b.Period)).
+			Column(sq.Expr("?", b.Anchor)).
+			Column(sq.Expr("?", b.Thresholds)).
+			From("categories").
+			Where(sq.Eq{"id": b.CategoryID, "group_id": gid})).
 		MustSQL()
-	_, err := r.db.Exec(s, args...)
+	return affected(r.db.Exec(s, args...))
+}
+
+func (r *repository) UpdateCategory(c models.Category) error {
+	s, args := SQL.Update("categories").
+		Set("name", c.Name).
+		Set("emoji", c.Emoji).
+		Set("color", c.Color).
+		Where(sq.Eq{"id": c.ID, "group_id": c.GroupID}).
+		MustSQL()
+	return affected(r.db.Exec(s, args...))
+}
+
+// DeleteCategory deletes the category, after moving everything in it onto
+// reassign if given. Without reassign, the category must be unused.
+func (r *repository) DeleteCategory(gid int64, cid types.ID, reassign *types.ID) error {
+	if reassign != nil {
+		return r.MergeCategories(gid, []types.ID{cid}, *reassign)
+	}
+
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := checkCategory(tx, gid, cid); err != nil {
+		return err
+	}
+	for _, table := range []string{"transactions", "splits", "recurrences"} {
+		s, args := SQL.Select("1").
+			From(table).
+			Where(sq.Eq{"category_id": cid}).
+			Limit(1).
+			MustSQL()
+		err := tx.Get(new(int), s, args...)
+		if err == nil {
+			return ErrCategoryUsed
+		}
+		if err != ErrNoRows {
+			return err
+		}
+	}
+
+	// foreign keys are not enforced, so remove dependent rows explicitly
+	for _, table := range []string{"budgets", "notifications"} {
+		s, args := SQL.Delete(table).
+			Where(sq.Eq{"category_id": cid}).
+			MustSQL()
+		if _, err := tx.Exec(s, args...); err != nil {
+			return err
+		}
+	}
+	s, args := SQL.Delete("categories").
+		Where(sq.Eq{"id": cid, "group_id": gid}).
+		MustSQL()
+	if _, err := tx.Exec(s, args...); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *repository) MergeCategories(gid int64, sources []types.ID, target types.ID) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var t models.Category
+	s, args := SQL.Select("*").
+		From("categories").
+		Where(sq.Eq{"id": target, "group_id": gid}).
+		MustSQL()
+	if err := tx.Get(&t, s, args...); err != nil {
+		return err
+	}
+
+	// sources given more than once are merged once
+	sources = slices.Clone(sources)
+	slices.SortFunc(sources, func(x, y types.ID) int { return x.Compare(y.ULID) })
+	for _, cid := range slices.Compact(sources) {
+		if cid == target {
+			return ErrCategorySelf
+		}
+
+		var c models.Category
+		s, args = SQL.Select("*").
+			From("categories").
+			Where(sq.Eq{"id": cid, "group_id": gid}).
+			MustSQL()
+		if err := tx.Get(&c, s, args...); err != nil {
+			return err
+		}
+		if c.Type != t.Type {
+			return ErrCategoryType
+		}
+
+		for _, table := range []string{"transactions", "splits", "recurrences"} {
+			s, args = SQL.Update(table).
+				Set("category_id", target).
+				Where(sq.Eq{"category_id": cid}).
+				MustSQL()
+			if _, err := tx.Exec(s, args...); err != nil {
+				return err
+			}
+		}
+
+		if err := mergeBudget(tx, cid, target); err != nil {
+			return err
+		}
+
+		for _, table := range []string{"budgets", "notifications"} {
+			s, args = SQL.Delete(table).
+				Where(sq.Eq{"category_id": cid}).
+				MustSQL()
+			if _, err := tx.Exec(s, args...); err != nil {
+				return err
+			}
+		}
+
+		s, args = SQL.Delete("categories").
+			Where(sq.Eq{"id": cid}).
+			MustSQL()
+		if _, err := tx.Exec(s, args...); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// mergeBudget adds the budget of category cid, if any, onto the budget of
+// target, which takes it as it is if target has none. Amounts of budgets of
+// different periods do not add up, so they are not merged.
+func mergeBudget(tx *sqlx.Tx, cid, target types.ID) error {
+	var b models.Budget
+	s, args := SQL.Select("*").
+		From("budgets").
+		Where(sq.Eq{"category_id": cid}).
+		MustSQL()
+	err := tx.Get(&b, s, args...)
+	if err == ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var period models.BudgetPeriod
+	s, args = SQL.Select("period").
+		From("budgets").
+		Where(sq.Eq{"category_id": target}).
+		MustSQL()
+	err = tx.Get(&period, s, args...)
+	if err == ErrNoRows {
+		s, args = SQL.Insert("budgets").
+			Columns("category_id", "amount", "period", "anchor", "thresholds").
+			Values(target, b.Amount, b.Period, b.Anchor, b.Thresholds).
+			MustSQL()
+		_, err = tx.Exec(s, args...)
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	if period != b.Period {
+		return ErrBudgetPeriod
+	}
+	s, args = SQL.Update("budgets").
+		Set("amount", sq.Expr("amount + ?", b.Amount)).
+		Where(sq.Eq{"category_id": target}).
+		MustSQL()
+	_, err = tx.Exec(s, args...)
+	return err
+}
+
+func (r *repository) UpdateTransaction(gid int64, t models.Transaction) error {
+	if err := checkCategory(r.db, gid, t.CategoryID); err != nil {
+		return err
+	}
+	if err := checkWallet(r.db, gid, t.WalletID); err != nil {
+		return err
+	}
+	// the category and amount of a split transaction follow its splits,
+	// checked only within the account like the update itself
+	s, args := SQL.Select("1").
+		From("transactions t").
+		Where(sq.Eq{"t.id": t.ID, "t.account_id": t.AccountID}).
+		Where(sq.Or{sq.NotEq{"t.category_id": t.CategoryID}, sq.NotEq{"t.amount": t.Amount}}).
+		Where("EXISTS (SELECT 1 FROM splits s WHERE s.transaction_id = t.id)").
+		MustSQL()
+	err := r.db.Get(new(int), s, args...)
+	if err == nil {
+		return ErrSplitChange
+	}
+	if err != ErrNoRows {
+		return err
+	}
+	s, args = SQL.Update("transactions").
+		Set("category_id", t.CategoryID).
+		Set("wallet_id", t.WalletID).
+		Set("amount", t.Amount).
+		Set("timestamp", t.Timestamp).
+		Set("title", t.Title).
+		Set("currency", t.Currency).
+		Where(sq.Eq{"id": t.ID, "account_id": t.AccountID}).
+		MustSQL()
+	return affected(r.db.Exec(s, args...))
+}
+
+func (r *repository) DeleteTransaction(aid int64, tid types.ID) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	s, args := SQL.Delete("transactions").
+		Where(sq.Eq{"id": tid, "account_id": aid}).
+		MustSQL()
+	if err := affected(tx.Exec(s, args...)); err != nil {
+		return err
+	}
+	for _, table := range []string{"splits", "transaction_tags"} {
+		s, args = SQL.Delete(table).
+			Where(sq.Eq{"transaction_id": tid}).
+			MustSQL()
+		if _, err := tx.Exec(s, args...); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// SetSplits replaces the splits of the transaction, or removes them if there
+// are none, and returns the transaction with the category of its largest
+// split.
+func (r *repository) SetSplits(gid int64, t models.Transaction, splits []models.Split) (models.Transaction, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return t, err
+	}
+	defer tx.Rollback()
+
+	if err := setSplits(tx, gid, &t, splits); err != nil {
+		return t, err
+	}
+	return t, tx.Commit()
+}
+
+func (r *repository) ListSplits(aid int64, tid types.ID) (ss []models.Split, err error) {
+	s, args := SQL.Select("s.*").
+		From("splits s").
+		Join("transactions t ON s.transaction_id = t.id").
+		Where(sq.Eq{"s.transaction_id": tid, "t.account_id": aid}).
+		OrderBy("s.amount DESC", "s.id").
+		MustSQL()
+	err = r.db.Select(&ss, s, args...)
+	return
+}
+
+// maxSplits limits the number of splits of a transaction.
+const maxSplits = 20
+
+// setSplits replaces the splits of transaction t, of which the categories
+// must belong to the group and be of the same type. Transaction t takes the
+// category of its largest split. Without splits, t is left as it is.
+func setSplits(tx *sqlx.Tx, gid int64, t *models.Transaction, splits []models.Split) error {
+	if len(splits) == 1 || len(splits) > maxSplits {
+		return ErrSplitCount
+	}
+	s, args := SQL.Delete("splits").
+		Where(sq.Eq{"transaction_id": t.ID}).
+		MustSQL()
+	if _, err := tx.Exec(s, args...); err != nil {
+		return err
+	}
+	if len(splits) == 0 {
+		return nil
+	}
+
+	var ct models.CategoryType
+	var total types.Money
+	largest := 0
+	for i, sp := range splits {
+		var c models.Category
+		s, args = SQL.Select("*").
+			From("categories").
+			Where(sq.Eq{"id": sp.CategoryID, "group_id": gid}).
+			MustSQL()
+		if err := tx.Get(&c, s, args...); err != nil {
+			return err
+		}
+		if i > 0 && c.Type != ct {
+			return ErrCategoryType
+		}
+		ct = c.Type
+		total += sp.Amount
+		if sp.Amount > splits[largest].Amount {
+			largest = i
+		}
+
+		s, args = SQL.Insert("splits").
+			Columns("id", "transaction_id", "category_id", "amount").
+			Values(types.MakeID(), t.ID, sp.CategoryID, sp.Amount).
+			MustSQL()
+		if _, err := tx.Exec(s, args...); err != nil {
+			return err
+		}
+	}
+	if total != t.Amount {
+		return ErrSplitSum
+	}
+
+	t.CategoryID = splits[largest].CategoryID
+	s, args = SQL.Update("transactions").
+		Set("category_id", t.CategoryID).
+		Where(sq.Eq{"id": t.ID}).
+		MustSQL()
+	_, err := tx.Exec(s, args...)
 	return err
 }
 
-func (r *repository) GetCategory(cid types.ID) (c models.Category, err error) {
+func (r *repository) SetBudget(gid int64, b models.Budget) error {
+	// select from categories so that budgets are only set within the group
+	s, args := SQL.Insert("budgets").
+		Columns("category_id", "amount", "period", "anchor", "thresholds").
+		Select(SQL.Select("id").
+			Column(sq.Expr("?", b.Amount)).
+			Column(sq.Expr("?", b.Period)).
+			Column(sq.Expr("?", b.Anchor)).
+			Column(sq.Expr("?", b.Thresholds)).
+			From("categories").
+			Where(sq.Eq{"id": b.CategoryID, "group_id": gid})).
+		Suffix("ON CONFLICT (category_id) DO UPDATE SET amount = excluded.amount, period = excluded.period, anchor = excluded.anchor, thresholds = excluded.thresholds").
+		MustSQL()
+	return affected(r.db.Exec(s, args...))
+}
+
+func (r *repository) RemoveBudget(gid int64, cid types.ID) error {
+	s, args := SQL.Delete("budgets").
+		Where(sq.Eq{"category_id": cid}).
+		Where("category_id IN (SELECT id FROM categories WHERE group_id = ?)", gid).
+		MustSQL()
+	return affected(r.db.Exec(s, args...))
+}
+
+func (r *repository) GetCategory(gid int64, cid types.ID) (c models.Category, err error) {
 	s, args := SQL.Select("*").
 		From("categories").
-		Where(sq.Eq{"id": cid}).
+		Where(sq.Eq{"id": cid, "group_id": gid}).
 		MustSQL()
 	err = r.db.Get(&c, s, args...)
 	return
@@ -151,19 +673,20 @@ func (r *repository) GetCategories(gid int64, ct *models.CategoryType) (c []mode
 	return
 }
 
-func (r *repository) GetTransaction(tid types.ID) (t models.Transaction, err error) {
+func (r *repository) GetTransaction(aid int64, tid types.ID) (t models.Transaction, err error) {
 	s, args := SQL.Select("*").
 		From("transactions").
-		Where(sq.Eq{"id": tid}).
+		Where(sq.Eq{"id": tid, "account_id": aid}).
 		MustSQL()
 	err = r.db.Get(&t, s, args...)
 	return
 }
 
-func (r *repository) GetBudget(cid types.ID) (b models.Budget, err error) {
-	s, args := SQL.Select("*").
-		From("budgets").
-		Where(sq.Eq{"category_id": cid}).
+func (r *repository) GetBudget(gid int64, cid types.ID) (b models.Budget, err error) {
+	s, args := SQL.Select("b.*").
+		From("budgets b").
+		Join("categories c ON b.category_id = c.id").
+		Where(sq.Eq{"b.category_id": cid, "c.group_id": gid}).
 		MustSQL()
 	err = r.db.Get(&b, s, args...)
 	if err != nil {
@@ -172,6 +695,21 @@ func (r *repository) GetBudget(cid types.ID) (b models.Budget, err error) {
 	return
 }
 
+func (r *repository) GetSpending(gid int64, cid types.ID, from, to types.Timestamp) (spent types.Money, err error) {
+	b := SQL.Select().
+		From("entries t").
+		Join("accounts a ON t.account_id = a.id").
+		Join("groups g ON a.group_id = g.id").
+		Where(sq.Eq{"g.id": gid, "t.category_id": cid})
+	b = filterTransactions(b, models.TransactionFilter{From: &from, To: &to})
+	if err = checkRates(r.db, b); err != nil {
+		return
+	}
+	s, args := b.Column("IFNULL(SUM(" + baseAmount + "), 0)").MustSQL()
+	err = r.db.Get(&spent, s, args...)
+	return
+}
+
 func (r *repository) GetBudgets(gid int64) (b []models.Budget, err error) {
 	s, args := SQL.Select("b.*").
 		From("budgets b").
@@ -187,7 +725,7 @@ func (r *repository) GetBudgets(gid int64) (b []models.Budget, err error) {
 
 func (r *repository) GetAccount(aid int64) (a models.Account, err error) {
 	// explicitly select columns to hide passhash
-	s, args := SQL.Select("id", "group_id", "email", "fullname").
+	s, args := SQL.Select("id", "group_id", "email", "fullname", "role").
 		From("accounts").
 		Where(sq.Eq{"id": aid}).
 		MustSQL()
@@ -195,19 +733,259 @@ func (r *repository) GetAccount(aid int64) (a models.Account, err error) {
 	return
 }
 
-func (r *repository) GetAccountSummary(aid int64) (as models.AccountSummary, err error) {
-	s, args := SQL.Select(
-		`IFNULL(SUM(CASE WHEN c.type = 'INCOME' THEN t.amount ELSE 0 END), 0) AS income`,
-		`IFNULL(SUM(CASE WHEN c.type = 'EXPENSE' THEN t.amount ELSE 0 END), 0) AS expense`,
-	).
-		From("transactions t").
+func (r *repository) GetGroup(gid int64) (g models.Group, err error) {
+	s, args := SQL.Select("*").
+		From("groups").
+		Where(sq.Eq{"id": gid}).
+		MustSQL()
+	err = r.db.Get(&g, s, args...)
+	return
+}
+
+func (r *repository) GetAccountSummary(aid int64, from, to *types.Timestamp) (as models.AccountSummary, err error) {
+	b := filterTransactions(SQL.Select().
+		From("entries t").
 		Join("categories c ON t.category_id = c.id").
-		Where(sq.Eq{"t.account_id": aid}).
+		Join("accounts a ON t.account_id = a.id").
+		Join("groups g ON a.group_id = g.id").
+		Where(sq.Eq{"t.account_id": aid}), models.TransactionFilter{From: from, To: to})
+	if err = checkRates(r.db, b); err != nil {
+		return
+	}
+	s, args := b.Column(sq.Expr(`(SELECT g.currency FROM groups g JOIN accounts a ON a.group_id = g.id WHERE a.id = ?) AS currency`, aid)).
+		Column(`IFNULL(SUM(CASE WHEN c.type = 'INCOME' THEN ` + baseAmount + ` ELSE 0 END), 0) AS income`).
+		Column(`IFNULL(SUM(CASE WHEN c.type = 'EXPENSE' THEN ` + baseAmount + ` ELSE 0 END), 0) AS expense`).
 		MustSQL()
 	err = r.db.Get(&as, s, args...)
+	as.AccountID, as.From, as.To = aid, from, to
+	return
+}
+
+func (r *repository) GetCategorySummaries(aid int64, from, to *types.Timestamp) (cs []models.CategorySummary, err error) {
+	b := filterTransactions(SQL.Select().
+		From("entries t").
+		Join("categories c ON t.category_id = c.id").
+		Join("accounts a ON t.account_id = a.id").
+		Join("groups g ON a.group_id = g.id").
+		Where(sq.Eq{"t.account_id": aid}), models.TransactionFilter{From: from, To: to})
+	if err = checkRates(r.db, b); err != nil {
+		return
+	}
+	s, args := b.Columns("t.category_id", "c.type").
+		Column("SUM("+baseAmount+") AS total").
+		Column("COUNT(*) AS count").
+		GroupBy("t.category_id").
+		OrderBy("c.type", "total DESC").
+		MustSQL()
+	if err = r.db.Select(&cs, s, args...); err != nil {
+		return
+	}
+
+	totals := map[models.CategoryType]types.Money{}
+	for _, c := range cs {
+		totals[c.Type] += c.Total
+	}
+	for i, c := range cs {
+		if total := totals[c.Type]; total != 0 {
+			cs[i].Share = float64(c.Total) / float64(total)
+		}
+	}
+	return
+}
+
+// GetTagSummaries totals the transactions of the account by tag. Transactions
+// count towards each of their tags.
+func (r *repository) GetTagSummaries(aid int64, from, to *types.Timestamp) (ts []models.TagSummary, err error) {
+	b := filterTransactions(SQL.Select().
+		From("entries t").
+		Join("transaction_tags tt ON tt.transaction_id = t.id").
+		Join("tags tg ON tt.tag_id = tg.id").
+		Join("categories c ON t.category_id = c.id").
+		Join("accounts a ON t.account_id = a.id").
+		Join("groups g ON a.group_id = g.id").
+		Where(sq.Eq{"t.account_id": aid}), models.TransactionFilter{From: from, To: to})
+	if err = checkRates(r.db, b); err != nil {
+		return
+	}
+	s, args := b.Column("tt.tag_id").
+		Column(`IFNULL(SUM(CASE WHEN c.type = 'INCOME' THEN ` + baseAmount + ` ELSE 0 END), 0) AS income`).
+		Column(`IFNULL(SUM(CASE WHEN c.type = 'EXPENSE' THEN ` + baseAmount + ` ELSE 0 END), 0) AS expense`).
+		Column("COUNT(DISTINCT t.id) AS count").
+		GroupBy("tt.tag_id").
+		OrderBy("tg.name").
+		MustSQL()
+	err = r.db.Select(&ts, s, args...)
+	return
+}
+
+// maxIntervals limits the number of points in each time series.
+const maxIntervals = 1000
+
+// intervalStart computes the start of the interval that contains the
+// timestamp of transaction t, in agreement with models.Interval.Truncate.
+var intervalStart = map[models.Interval]string{
+	models.IntervalDay:   `CAST(strftime('%s', t.timestamp, 'unixepoch', 'start of day') AS INTEGER)`,
+	models.IntervalWeek:  `CAST(strftime('%s', t.timestamp, 'unixepoch', 'start of day', 'weekday 0', '-6 days') AS INTEGER)`,
+	models.IntervalMonth: `CAST(strftime('%s', t.timestamp, 'unixepoch', 'start of month') AS INTEGER)`,
+	models.IntervalYear:  `CAST(strftime('%s', t.timestamp, 'unixepoch', 'start of year') AS INTEGER)`,
+}
+
+func (r *repository) GetTimeSeries(aid int64, from, to types.Timestamp, i models.Interval, g models.TimeSeriesGroup) (series []models.TimeSeries, err error) {
+	var starts []time.Time
+	index := map[int64]int{} // position of each interval by its start
+	for t := i.Truncate(from.Time); t.Before(to.Time); t = i.Next(t) {
+		if len(starts) == maxIntervals {
+			return nil, ErrInterval
+		}
+		index[t.Unix()] = len(starts)
+		starts = append(starts, t)
+	}
+
+	b := SQL.Select().
+		From("entries t").
+		Join("categories c ON t.category_id = c.id").
+		Join("accounts a ON t.account_id = a.id").
+		Join("groups g ON a.group_id = g.id").
+		Where(sq.Eq{"t.account_id": aid}).
+		Where(sq.GtOrEq{"t.timestamp": from}).
+		Where(sq.Lt{"t.timestamp": to})
+	if err = checkRates(r.db, b); err != nil {
+		return
+	}
+	key := "NULL"
+	if g == models.TimeSeriesGroupCategory {
+		key = "t.category_id"
+	}
+	s, args := b.Column("c.type").
+		Column(key+" AS cid").
+		Column(intervalStart[i]+" AS start").
+		Column("SUM("+baseAmount+") AS amount").
+		GroupBy("c.type", "cid", "start").
+		OrderBy("c.type", "cid", "start").
+		MustSQL()
+	var rows []struct {
+		Type       models.CategoryType `db:"type"`
+		CategoryID *types.ID           `db:"cid"`
+		Start      types.Timestamp     `db:"start"`
+		Amount     types.Money         `db:"amount"`
+	}
+	if err = r.db.Select(&rows, s, args...); err != nil {
+		return
+	}
+
+	// a series for each type, or each category of the group, filled with
+	// zeros for the intervals without transactions
+	type seriesKey struct {
+		typ models.CategoryType
+		cid types.ID
+	}
+	var keys []seriesKey
+	if g == models.TimeSeriesGroupCategory {
+		s, args := SQL.Select("c.type", "c.id").
+			From("categories c").
+			Join("accounts a ON c.group_id = a.group_id").
+			Where(sq.Eq{"a.id": aid}).
+			OrderBy("c.type", "c.id").
+			MustSQL()
+		var cs []models.Category
+		if err = r.db.Select(&cs, s, args...); err != nil {
+			return
+		}
+		for _, c := range cs {
+			keys = append(keys, seriesKey{c.Type, c.ID})
+		}
+	} else {
+		keys = []seriesKey{{typ: models.CategoryTypeExpense}, {typ: models.CategoryTypeIncome}}
+	}
+	series = make([]models.TimeSeries, len(keys))
+	position := map[seriesKey]int{}
+	for n, k := range keys {
+		series[n] = models.TimeSeries{Type: k.typ, Points: make([]models.TimeSeriesPoint, len(starts))}
+		if cid := k.cid; !cid.IsZero() {
+			series[n].CategoryID = &cid
+		}
+		for j, start := range starts {
+			series[n].Points[j].Timestamp = types.Timestamp{Time: start}
+		}
+		position[k] = n
+	}
+
+	for _, row := range rows {
+		k := seriesKey{typ: row.Type}
+		if row.CategoryID != nil {
+			k.cid = *row.CategoryID
+		}
+		if n, ok := position[k]; ok {
+			series[n].Points[index[row.Start.Unix()]].Amount = row.Amount
+		}
+	}
 	return
 }
 
+// baseAmount converts the amount of transaction t into the base currency of
+// group g, at the latest exchange rate on or before the date of t in either
+// direction. It is NULL when there is no such rate.
+const baseAmount = `(CASE WHEN t.currency = g.currency THEN t.amount ELSE CAST(ROUND(t.amount * COALESCE(
+	(SELECT r.rate FROM exchange_rates r
+		WHERE r."from" = t.currency AND r."to" = g.currency AND r.date <= DATE(t.timestamp, 'unixepoch')
+		ORDER BY r.date DESC LIMIT 1),
+	(SELECT 1 / r.rate FROM exchange_rates r
+		WHERE r."from" = g.currency AND r."to" = t.currency AND r.date <= DATE(t.timestamp, 'unixepoch')
+		ORDER BY r.date DESC LIMIT 1)
+)) AS INTEGER) END)`
+
+// checkRates reports ErrExchangeRate for the first transaction selected by b,
+// which joins transactions t with groups g, that cannot be converted into the
+// base currency.
+func checkRates(q sqlx.Queryer, b sq.SelectBuilder) error {
+	var missing struct {
+		From string `db:"from"`
+		To   string `db:"to"`
+		Date string `db:"date"`
+	}
+	s, args := b.Columns("t.currency AS \"from\"", "g.currency AS \"to\"", "DATE(t.timestamp, 'unixepoch') AS date").
+		Where(baseAmount + " IS NULL").
+		Limit(1).
+		MustSQL()
+	err := sqlx.Get(q, &missing, s, args...)
+	if err == ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return fmt.Errorf("%w from %s to %s on %s", ErrExchangeRate, missing.From, missing.To, missing.Date)
+}
+
+func (r *repository) UpdateGroup(g models.Group) error {
+	s, args := SQL.Update("groups").
+		Set("currency", g.Currency).
+		Where(sq.Eq{"id": g.ID}).
+		MustSQL()
+	return affected(r.db.Exec(s, args...))
+}
+
+func (r *repository) ImportExchangeRates(rates []models.ExchangeRate) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, rate := range rates {
+		s, args := SQL.Insert("exchange_rates").
+			Columns("date", `"from"`, `"to"`, "rate").
+			Values(rate.Date, rate.From, rate.To, rate.Rate).
+			Suffix("ON CONFLICT DO UPDATE SET rate = excluded.rate").
+			MustSQL()
+		if _, err := tx.Exec(s, args...); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
 func (r *repository) CreateAccount(a models.Account, key string) (int64, error) {
 	s, args := SQL.Select("key").
 		From("licensekeys").
@@ -245,6 +1023,9 @@ func (r *repository) CreateAccount(a models.Account, key string) (int64, error)
 	if err != nil {
 		return -1, err
 	}
+	if _, err := createWallet(tx, models.Wallet{GroupID: gid, Name: models.DefaultWallet}); err != nil {
+		return -1, err
+	}
 
 	s, args = SQL.Insert("accounts").
 		Columns("id", "group_id", "email", "fullname", "passhash").
@@ -271,19 +1052,1674 @@ func (r *repository) FindAccountByEmail(email string) (a models.Account, err err
 	return
 }
 
-func (r *repository) ListTransactions(aid int64, cid *types.ID, ct *models.CategoryType) (results []models.Transaction, err error) {
-	b := SQL.Select("t.*").
-		From("transactions t").
-		Where(sq.Eq{"t.account_id": aid}).
-		OrderBy("t.timestamp DESC")
-	if cid != nil {
-		b = b.Where(sq.Eq{"t.category_id": cid})
+// DeleteAccount deletes the account with its transactions, recurrences and
+// transfers, and the group too if the account was its last member.
+func (r *repository) DeleteAccount(aid int64) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
 	}
-	if ct != nil {
-		b = b.Join("categories c ON t.category_id = c.id").
-			Where(sq.Eq{"c.type": *ct})
+	defer tx.Rollback()
+
+	var gid int64
+	s, args := SQL.Select("group_id").
+		From("accounts").
+		Where(sq.Eq{"id": aid}).
+		MustSQL()
+	if err := tx.Get(&gid, s, args...); err != nil {
+		return err
 	}
-	s, args := b.MustSQL()
-	err = r.db.Select(&results, s, args...)
-	return
+
+	for _, table := range []string{"splits", "transaction_tags"} {
+		s, args = SQL.Delete(table).
+			Where(sq.Expr("transaction_id IN (SELECT id FROM transactions WHERE account_id = ?)", aid)).
+			MustSQL()
+		if _, err := tx.Exec(s, args...); err != nil {
+			return err
+		}
+	}
+	for _, table := range []string{"transactions", "recurrences", "transfers"} {
+		s, args = SQL.Delete(table).
+			Where(sq.Eq{"account_id": aid}).
+			MustSQL()
+		if _, err := tx.Exec(s, args...); err != nil {
+			return err
+		}
+	}
+	s, args = SQL.Delete("accounts").
+		Where(sq.Eq{"id": aid}).
+		MustSQL()
+	if _, err := tx.Exec(s, args...); err != nil {
+		return err
+	}
+	if err := abandonGroup(tx, gid); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// abandonGroup is called after a member left the group. The earliest member
+// left becomes the owner if the group has none, or the group and everything
+// it owns is deleted if no member is left. Transactions and transfers belong
+// to accounts, so they are gone already.
+func abandonGroup(tx *sqlx.Tx, gid int64) error {
+	var members int
+	s, args := SQL.Select("COUNT(*)").
+		From("accounts").
+		Where(sq.Eq{"group_id": gid}).
+		MustSQL()
+	if err := tx.Get(&members, s, args...); err != nil {
+		return err
+	}
+	if members > 0 {
+		s, args = SQL.Update("accounts").
+			Set("role", models.RoleOwner).
+			Where(sq.Expr("id = (SELECT MIN(id) FROM accounts WHERE group_id = ?)", gid)).
+			Where(sq.Expr("NOT EXISTS (SELECT 1 FROM accounts WHERE group_id = ? AND role = ?)", gid, models.RoleOwner)).
+			MustSQL()
+		_, err := tx.Exec(s, args...)
+		return err
+	}
+
+	categories := subquery.Select("id").
+		From("categories").
+		Where(sq.Eq{"group_id": gid})
+	for _, table := range []string{"budgets", "notifications"} {
+		s, args = SQL.Delete(table).
+			Where(sq.Expr("category_id IN (?)", categories)).
+			MustSQL()
+		if _, err := tx.Exec(s, args...); err != nil {
+			return err
+		}
+	}
+	for _, table := range []string{"categories", "wallets", "tags", "invitations"} {
+		s, args = SQL.Delete(table).
+			Where(sq.Eq{"group_id": gid}).
+			MustSQL()
+		if _, err := tx.Exec(s, args...); err != nil {
+			return err
+		}
+	}
+	s, args = SQL.Delete("groups").
+		Where(sq.Eq{"id": gid}).
+		MustSQL()
+	_, err := tx.Exec(s, args...)
+	return err
+}
+
+func (r *repository) ExportGroup(gid int64) (a models.Archive, err error) {
+	a.Version = models.ArchiveVersion
+	a.Timestamp = types.Timestamp{Time: time.Now().UTC()}
+	if a.Group, err = r.GetGroup(gid); err != nil {
+		return
+	}
+
+	members := subquery.Select("id").
+		From("accounts").
+		Where(sq.Eq{"group_id": gid})
+	categories := subquery.Select("id").
+		From("categories").
+		Where(sq.Eq{"group_id": gid})
+	transactions := subquery.Select("id").
+		From("transactions").
+		Where(sq.Expr("account_id IN (?)", members))
+	for _, q := range []struct {
+		dest  any
+		table string
+		where sq.Sqlizer
+	}{
+		{&a.Categories, "categories", sq.Eq{"group_id": gid}},
+		{&a.Wallets, "wallets", sq.Eq{"group_id": gid}},
+		{&a.Transactions, "transactions", sq.Expr("account_id IN (?)", members)},
+		{&a.Splits, "splits", sq.Expr("transaction_id IN (?)", transactions)},
+		{&a.Budgets, "budgets", sq.Expr("category_id IN (?)", categories)},
+		{&a.Recurrences, "recurrences", sq.Expr("account_id IN (?)", members)},
+		{&a.Transfers, "transfers", sq.Expr("account_id IN (?)", members)},
+		{&a.Tags, "tags", sq.Eq{"group_id": gid}},
+		{&a.TransactionTags, "transaction_tags", sq.Expr("transaction_id IN (?)", transactions)},
+		{&a.Notifications, "notifications", sq.Eq{"group_id": gid}},
+	} {
+		s, args := SQL.Select("*").
+			From(q.table).
+			Where(q.where).
+			MustSQL()
+		if err = r.db.Select(q.dest, s, args...); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// ImportGroup recreates the archive under a new group with new IDs, and moves
+// the account into it. Transactions, recurrences and transfers of every
+// member in the archive are given to the account, which must have none of its
+// own yet. The previous group of the account is deleted if it has no other
+// members.
+func (r *repository) ImportGroup(aid int64, a models.Archive) (gid int64, err error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return
+	}
+	defer tx.Rollback()
+
+	var prev int64
+	s, args := SQL.Select("group_id").
+		From("accounts").
+		Where(sq.Eq{"id": aid}).
+		MustSQL()
+	if err = tx.Get(&prev, s, args...); err != nil {
+		return
+	}
+	var owned bool
+	err = tx.Get(&owned, `SELECT EXISTS (SELECT 1 FROM transactions WHERE account_id = $1)
+		OR EXISTS (SELECT 1 FROM recurrences WHERE account_id = $1)
+		OR EXISTS (SELECT 1 FROM transfers WHERE account_id = $1)`, aid)
+	if err != nil {
+		return
+	}
+	if owned {
+		return 0, ErrNotEmpty
+	}
+
+	s, args = SQL.Insert("groups").
+		Columns("currency").
+		Values(a.Group.Currency).
+		MustSQL()
+	result, err := tx.Exec(s, args...)
+	if err != nil {
+		return
+	}
+	if gid, err = result.LastInsertId(); err != nil {
+		return
+	}
+
+	// map the IDs in the archive to new ones
+	ids := make(map[types.ID]types.ID)
+	mapID := func(id types.ID) (types.ID, error) {
+		if id.IsZero() {
+			return id, nil
+		}
+		if id, ok := ids[id]; ok {
+			return id, nil
+		}
+		return id, ErrArchive
+	}
+
+	for _, c := range a.Categories {
+		ids[c.ID] = types.MakeID()
+		s, args = SQL.Insert("categories").
+			Columns("id", "group_id", "name", "type", "emoji", "color").
+			Values(ids[c.ID], gid, c.Name, c.Type, c.Emoji, c.Color).
+			MustSQL()
+		if _, err = tx.Exec(s, args...); err != nil {
+			return
+		}
+	}
+	// the earliest wallet is the default, so wallets are created in order
+	slices.SortFunc(a.Wallets, func(x, y models.Wallet) int { return x.ID.Compare(y.ID.ULID) })
+	for _, w := range a.Wallets {
+		w.GroupID = gid
+		if ids[w.ID], err = createWallet(tx, w); err != nil {
+			return
+		}
+	}
+	// archives from before wallets existed have everything in a default wallet
+	var def types.ID
+	if len(a.Wallets) == 0 {
+		if def, err = createWallet(tx, models.Wallet{GroupID: gid, Name: models.DefaultWallet}); err != nil {
+			return
+		}
+	}
+	mapWallet := func(id types.ID) (types.ID, error) {
+		if id.IsZero() {
+			if def.IsZero() {
+				return id, ErrArchive
+			}
+			return def, nil
+		}
+		return mapID(id)
+	}
+
+	for _, rec := range a.Recurrences {
+		ids[rec.ID] = types.MakeID()
+		if rec.CategoryID, err = mapID(rec.CategoryID); err != nil {
+			return
+		}
+		if rec.WalletID, err = mapWallet(rec.WalletID); err != nil {
+			return
+		}
+		s, args = SQL.Insert("recurrences").
+			Columns("id", "account_id", "category_id", "wallet_id", "title", "amount", "currency",
+				"frequency", "interval", "start", "until", "count", "next", "next_at").
+			Values(ids[rec.ID], aid, rec.CategoryID, rec.WalletID, rec.Title, rec.Amount, rec.Currency,
+				rec.Frequency, rec.Interval, rec.Start, rec.Until, rec.Count, rec.Next, rec.NextAt).
+			MustSQL()
+		if _, err = tx.Exec(s, args...); err != nil {
+			return
+		}
+	}
+	for _, t := range a.Transactions {
+		ids[t.ID] = types.MakeID()
+		if t.CategoryID, err = mapID(t.CategoryID); err != nil {
+			return
+		}
+		if t.RecurrenceID, err = mapID(t.RecurrenceID); err != nil {
+			return
+		}
+		if t.WalletID, err = mapWallet(t.WalletID); err != nil {
+			return
+		}
+		s, args = SQL.Insert("transactions").
+			Columns("id", "account_id", "category_id", "wallet_id", "amount", "timestamp", "title", "currency", "recurrence_id", "external_id").
+			Values(ids[t.ID], aid, t.CategoryID, t.WalletID, t.Amount, t.Timestamp, t.Title, t.Currency, t.RecurrenceID, t.ExternalID).
+			MustSQL()
+		if _, err = tx.Exec(s, args...); err != nil {
+			return
+		}
+	}
+	for _, t := range a.Transfers {
+		if t.FromWalletID, err = mapWallet(t.FromWalletID); err != nil {
+			return
+		}
+		if t.ToWalletID, err = mapWallet(t.ToWalletID); err != nil {
+			return
+		}
+		s, args = SQL.Insert("transfers").
+			Columns("id", "account_id", "from_wallet_id", "to_wallet_id", "amount", "currency", "title", "timestamp").
+			Values(types.MakeID(), aid, t.FromWalletID, t.ToWalletID, t.Amount, t.Currency, t.Title, t.Timestamp).
+			MustSQL()
+		if _, err = tx.Exec(s, args...); err != nil {
+			return
+		}
+	}
+	for _, tag := range a.Tags {
+		ids[tag.ID] = types.MakeID()
+		s, args = SQL.Insert("tags").
+			Columns("id", "group_id", "name").
+			Values(ids[tag.ID], gid, tag.Name).
+			MustSQL()
+		if _, err = tx.Exec(s, args...); err != nil {
+			return
+		}
+	}
+	for _, tt := range a.TransactionTags {
+		if tt.TransactionID, err = mapID(tt.TransactionID); err != nil {
+			return
+		}
+		if tt.TagID, err = mapID(tt.TagID); err != nil {
+			return
+		}
+		s, args = SQL.Insert("transaction_tags").
+			Columns("transaction_id", "tag_id").
+			Values(tt.TransactionID, tt.TagID).
+			MustSQL()
+		if _, err = tx.Exec(s, args...); err != nil {
+			return
+		}
+	}
+	for _, sp := range a.Splits {
+		if sp.TransactionID, err = mapID(sp.TransactionID); err != nil {
+			return
+		}
+		if sp.CategoryID, err = mapID(sp.CategoryID); err != nil {
+			return
+		}
+		s, args = SQL.Insert("splits").
+			Columns("id", "transaction_id", "category_id", "amount").
+			Values(types.MakeID(), sp.TransactionID, sp.CategoryID, sp.Amount).
+			MustSQL()
+		if _, err = tx.Exec(s, args...); err != nil {
+			return
+		}
+	}
+	for _, b := range a.Budgets {
+		if b.CategoryID, err = mapID(b.CategoryID); err != nil {
+			return
+		}
+		s, args = SQL.Insert("budgets").
+			Columns("category_id", "amount", "period", "anchor", "thresholds").
+			Values(b.CategoryID, b.Amount, b.Period, b.Anchor, b.Thresholds).
+			MustSQL()
+		if _, err = tx.Exec(s, args...); err != nil {
+			return
+		}
+	}
+	for _, n := range a.Notifications {
+		if n.CategoryID, err = mapID(n.CategoryID); err != nil {
+			return
+		}
+		s, args = SQL.Insert("notifications").
+			Columns("id", "group_id", "category_id", "threshold", "period_start", "amount", "spent", "timestamp", "read").
+			Values(types.MakeID(), gid, n.CategoryID, n.Threshold, n.PeriodStart, n.Amount, n.Spent, n.Timestamp, n.Read).
+			MustSQL()
+		if _, err = tx.Exec(s, args...); err != nil {
+			return
+		}
+	}
+
+	s, args = SQL.Update("accounts").
+		Set("group_id", gid).
+		Set("role", models.RoleOwner).
+		Where(sq.Eq{"id": aid}).
+		MustSQL()
+	if _, err = tx.Exec(s, args...); err != nil {
+		return
+	}
+	if err = abandonGroup(tx, prev); err != nil {
+		return
+	}
+
+	err = tx.Commit()
+	return
+}
+
+// filterTransactions narrows a query over "transactions t" down to the rows
+// matching f. The time range is half-open: [From, To).
+func filterTransactions(b sq.SelectBuilder, f models.TransactionFilter) sq.SelectBuilder {
+	if f.From != nil {
+		b = b.Where(sq.GtOrEq{"t.timestamp": f.From})
+	}
+	if f.To != nil {
+		b = b.Where(sq.Lt{"t.timestamp": f.To})
+	}
+	if f.MinAmount != nil {
+		b = b.Where(sq.GtOrEq{"t.amount": *f.MinAmount})
+	}
+	if f.MaxAmount != nil {
+		b = b.Where(sq.LtOrEq{"t.amount": *f.MaxAmount})
+	}
+	if f.Title != nil && *f.Title != "" {
+		// LIKE is case-insensitive in SQLite
+		pattern := "%" + likeEscaper.Replace(*f.Title) + "%"
+		b = b.Where(`t.title LIKE ? ESCAPE '\'`, pattern)
+	}
+	if f.Categories != nil {
+		b = b.Where(inCategories(f.Categories))
+	}
+	if f.Wallets != nil {
+		b = b.Where(sq.Eq{"t.wallet_id": f.Wallets})
+	}
+	if f.Tags != nil {
+		b = b.Where(hasTags(f.Tags, f.TagMatch))
+	}
+	if f.Type != nil {
+		b = b.Join("categories c ON t.category_id = c.id").
+			Where(sq.Eq{"c.type": *f.Type})
+	}
+	return b
+}
+
+// inCategories matches the transactions t, or any of their splits, in the
+// categories.
+func inCategories(categories any) sq.Sqlizer {
+	return sq.Or{
+		sq.Eq{"t.category_id": categories},
+		sq.Expr("t.id IN (?)", subquery.Select("transaction_id").
+			From("splits").
+			Where(sq.Eq{"category_id": categories})),
+	}
+}
+
+// hasTags matches the transactions t with any of the tags, or with all of
+// them if match is TagMatchAll.
+func hasTags(tags []types.ID, match models.TagMatch) sq.Sqlizer {
+	q := subquery.Select("transaction_id").
+		From("transaction_tags").
+		Where(sq.Eq{"tag_id": tags})
+	if match == models.TagMatchAll {
+		// tags given more than once are counted once
+		unique := slices.Clone(tags)
+		slices.SortFunc(unique, func(x, y types.ID) int { return x.Compare(y.ULID) })
+		q = q.GroupBy("transaction_id").
+			Having("COUNT(*) = ?", len(slices.Compact(unique)))
+	}
+	return sq.Expr("t.id IN (?)", q)
+}
+
+// pageSize returns the size of a page given n items asked for, if at all.
+func pageSize(n *int) int {
+	if n == nil {
+		return models.DefaultPageSize
+	}
+	return min(*n, models.MaxPageSize)
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+func (r *repository) ListTransactions(aid int64, cid *types.ID, f models.TransactionFilter, p models.Page) (conn models.TransactionConnection, err error) {
+	if (p.First != nil && p.Last != nil) ||
+		(p.First != nil && *p.First < 0) ||
+		(p.Last != nil && *p.Last < 0) ||
+		(p.After != nil && p.After.IsOffset()) ||
+		(p.Before != nil && p.Before.IsOffset()) {
+		return conn, ErrPage
+	}
+
+	b := filterTransactions(SQL.Select().
+		From("transactions t").
+		Where(sq.Eq{"t.account_id": aid}), f)
+	if cid != nil {
+		b = b.Where(inCategories(cid))
+	}
+
+	s, args := b.Column("COUNT(*)").MustSQL()
+	if err = r.db.Get(&conn.TotalCount, s, args...); err != nil {
+		return
+	}
+
+	// rows are ordered by (timestamp, id), so that the order stays
+	// stable among transactions sharing the same timestamp
+	lt, gt, forward, backward := "<", ">", "DESC", "ASC"
+	if f.Order == models.SortOrderAsc {
+		lt, gt, forward, backward = gt, lt, backward, forward
+	}
+	b = b.Column("t.*")
+	if p.After != nil {
+		b = b.Where("(t.timestamp, t.id) "+lt+" (?, ?)", p.After.Timestamp, p.After.ID)
+	}
+	if p.Before != nil {
+		b = b.Where("(t.timestamp, t.id) "+gt+" (?, ?)", p.Before.Timestamp, p.Before.ID)
+	}
+	limit := pageSize(p.First)
+	if p.Last != nil {
+		// walk backwards from the end and reverse the results afterwards
+		limit = pageSize(p.Last)
+		b = b.OrderBy("t.timestamp "+backward, "t.id "+backward)
+	} else {
+		b = b.OrderBy("t.timestamp "+forward, "t.id "+forward)
+	}
+	// fetch one more row to tell whether there are more pages
+	b = b.Limit(uint64(limit) + 1)
+
+	var results []models.Transaction
+	s, args = b.MustSQL()
+	if err = r.db.Select(&results, s, args...); err != nil {
+		return
+	}
+
+	more := len(results) > limit
+	if more {
+		results = results[:limit]
+	}
+	if p.Last != nil {
+		slices.Reverse(results)
+		conn.PageInfo.HasPreviousPage = more
+		conn.PageInfo.HasNextPage = p.Before != nil
+	} else {
+		conn.PageInfo.HasNextPage = more
+		conn.PageInfo.HasPreviousPage = p.After != nil
+	}
+
+	conn.Edges = make([]models.TransactionEdge, len(results))
+	for i, t := range results {
+		conn.Edges[i] = models.TransactionEdge{
+			Cursor: types.Cursor{Timestamp: t.Timestamp, ID: t.ID},
+			Node:   t,
+		}
+	}
+	if n := len(conn.Edges); n > 0 {
+		conn.PageInfo.StartCursor = &conn.Edges[0].Cursor
+		conn.PageInfo.EndCursor = &conn.Edges[n-1].Cursor
+	}
+	return
+}
+
+// matchQuery turns free text into an FTS5 query matching every word of it
+// as a prefix, so that user input cannot inject query syntax.
+func matchQuery(text string) string {
+	words := strings.Fields(text)
+	for i, w := range words {
+		words[i] = `"` + strings.ReplaceAll(w, `"`, `""`) + `"*`
+	}
+	return strings.Join(words, " ")
+}
+
+func (r *repository) SearchTransactions(aid int64, text string, p models.Page) (conn models.SearchConnection, err error) {
+	if (p.First != nil && *p.First < 0) ||
+		(p.After != nil && !p.After.IsOffset()) {
+		return conn, ErrPage
+	}
+
+	conn.Edges = []models.SearchEdge{}
+	match := matchQuery(text)
+	if match == "" {
+		return
+	}
+
+	b := SQL.Select().
+		From("transactions_fts").
+		Join("transactions t ON t.id = transactions_fts.id").
+		Where("transactions_fts MATCH ?", match).
+		Where(sq.Eq{"t.account_id": aid})
+
+	s, args := b.Column("COUNT(*)").MustSQL()
+	if err = r.db.Get(&conn.TotalCount, s, args...); err != nil {
+		return
+	}
+
+	offset := 0
+	if p.After != nil {
+		offset = p.After.Offset
+	}
+	limit := pageSize(p.First)
+	// fetch one more row to tell whether there are more pages
+	b = b.Columns("t.*", `snippet(transactions_fts, 1, '<mark>', '</mark>', '…', 10) AS snippet`).
+		OrderBy("transactions_fts.rank", "t.id").
+		Limit(uint64(limit) + 1).
+		Offset(uint64(offset))
+
+	var results []struct {
+		models.Transaction
+		Snippet string `db:"snippet"`
+	}
+	s, args = b.MustSQL()
+	if err = r.db.Select(&results, s, args...); err != nil {
+		return
+	}
+
+	if len(results) > limit {
+		results = results[:limit]
+		conn.PageInfo.HasNextPage = true
+	}
+	conn.PageInfo.HasPreviousPage = offset > 0
+
+	conn.Edges = make([]models.SearchEdge, len(results))
+	for i, t := range results {
+		conn.Edges[i] = models.SearchEdge{
+			Cursor:  types.Cursor{Offset: offset + i + 1},
+			Node:    t.Transaction,
+			Snippet: t.Snippet,
+		}
+	}
+	if n := len(conn.Edges); n > 0 {
+		conn.PageInfo.StartCursor = &conn.Edges[0].Cursor
+		conn.PageInfo.EndCursor = &conn.Edges[n-1].Cursor
+	}
+	return
+}
+
+// ExportTransactions calls fn with each transaction matching the filter in
+// order, as they are read from the database.
+func (r *repository) ExportTransactions(aid int64, f models.TransactionFilter, fn func(models.TransactionExport) error) error {
+	// categories are joined here already
+	ct := f.Type
+	f.Type = nil
+	b := filterTransactions(SQL.Select("t.*").
+		Column("c.name AS category_name").
+		Column("c.type AS category_type").
+		Column("c.emoji AS category_emoji").
+		Column("w.name AS wallet_name").
+		From("transactions t").
+		Join("categories c ON t.category_id = c.id").
+		Join("wallets w ON t.wallet_id = w.id").
+		Where(sq.Eq{"t.account_id": aid}), f)
+	if ct != nil {
+		b = b.Where(sq.Eq{"c.type": *ct})
+	}
+	order := "DESC"
+	if f.Order == models.SortOrderAsc {
+		order = "ASC"
+	}
+	s, args := b.OrderBy("t.timestamp "+order, "t.id "+order).MustSQL()
+
+	rows, err := r.db.Queryx(s, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var t models.TransactionExport
+		if err := rows.StructScan(&t); err != nil {
+			return err
+		}
+		if err := fn(t); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func createWallet(tx sqlx.Execer, w models.Wallet) (types.ID, error) {
+	wid := types.MakeID()
+	s, args := SQL.Insert("wallets").
+		Columns("id", "group_id", "name", "opening_balance").
+		Values(wid, w.GroupID, w.Name, w.OpeningBalance).
+		MustSQL()
+	_, err := tx.Exec(s, args...)
+	return wid, err
+}
+
+func (r *repository) CreateWallet(w models.Wallet) (types.ID, error) {
+	return createWallet(r.db, w)
+}
+
+func (r *repository) UpdateWallet(w models.Wallet) error {
+	s, args := SQL.Update("wallets").
+		Set("name", w.Name).
+		Set("opening_balance", w.OpeningBalance).
+		Where(sq.Eq{"id": w.ID, "group_id": w.GroupID}).
+		MustSQL()
+	return affected(r.db.Exec(s, args...))
+}
+
+// DeleteWallet deletes the wallet, which the group must not be left without.
+// Its transactions, recurrences and transfers move to the wallet reassign,
+// which takes on its opening balance too, and transfers between the two are
+// deleted. Without reassign, the wallet must be unused.
+func (r *repository) DeleteWallet(gid int64, wid types.ID, reassign *types.ID) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var w models.Wallet
+	s, args := SQL.Select("*").
+		From("wallets").
+		Where(sq.Eq{"id": wid, "group_id": gid}).
+		MustSQL()
+	if err := tx.Get(&w, s, args...); err != nil {
+		return err
+	}
+	var n int
+	s, args = SQL.Select("COUNT(*)").
+		From("wallets").
+		Where(sq.Eq{"group_id": gid}).
+		MustSQL()
+	if err := tx.Get(&n, s, args...); err != nil {
+		return err
+	}
+	if n == 1 {
+		return ErrWalletLast
+	}
+
+	refs := []struct{ table, column string }{
+		{"transactions", "wallet_id"},
+		{"recurrences", "wallet_id"},
+		{"transfers", "from_wallet_id"},
+		{"transfers", "to_wallet_id"},
+	}
+	if reassign == nil {
+		for _, ref := range refs {
+			s, args = SQL.Select("1").
+				From(ref.table).
+				Where(sq.Eq{ref.column: wid}).
+				Limit(1).
+				MustSQL()
+			err := tx.Get(new(int), s, args...)
+			if err == nil {
+				return ErrWalletUsed
+			}
+			if err != ErrNoRows {
+				return err
+			}
+		}
+	} else {
+		target := *reassign
+		if target == wid {
+			return ErrWalletSelf
+		}
+		if err := checkWallet(tx, gid, target); err != nil {
+			return err
+		}
+		s, args = SQL.Delete("transfers").
+			Where(sq.Or{
+				sq.Eq{"from_wallet_id": wid, "to_wallet_id": target},
+				sq.Eq{"from_wallet_id": target, "to_wallet_id": wid},
+			}).
+			MustSQL()
+		if _, err := tx.Exec(s, args...); err != nil {
+			return err
+		}
+		for _, ref := range refs {
+			s, args = SQL.Update(ref.table).
+				Set(ref.column, target).
+				Where(sq.Eq{ref.column: wid}).
+				MustSQL()
+			if _, err := tx.Exec(s, args...); err != nil {
+				return err
+			}
+		}
+		s, args = SQL.Update("wallets").
+			Set("opening_balance", sq.Expr("opening_balance + ?", w.OpeningBalance)).
+			Where(sq.Eq{"id": target}).
+			MustSQL()
+		if _, err := tx.Exec(s, args...); err != nil {
+			return err
+		}
+	}
+
+	s, args = SQL.Delete("wallets").
+		Where(sq.Eq{"id": wid}).
+		MustSQL()
+	if _, err := tx.Exec(s, args...); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetWallet returns the wallet of the group, or its default wallet, the
+// earliest created, if wid is zero.
+func (r *repository) GetWallet(gid int64, wid types.ID) (w models.Wallet, err error) {
+	b := SQL.Select("*").
+		From("wallets").
+		Where(sq.Eq{"group_id": gid})
+	if wid.IsZero() {
+		b = b.OrderBy("id").Limit(1)
+	} else {
+		b = b.Where(sq.Eq{"id": wid})
+	}
+	s, args := b.MustSQL()
+	err = r.db.Get(&w, s, args...)
+	return
+}
+
+func (r *repository) ListWallets(gid int64) (ws []models.Wallet, err error) {
+	s, args := SQL.Select("*").
+		From("wallets").
+		Where(sq.Eq{"group_id": gid}).
+		OrderBy("id").
+		MustSQL()
+	err = r.db.Select(&ws, s, args...)
+	return
+}
+
+// GetBalance returns the balance of the wallet as of the time, inclusive: its
+// opening balance, plus income and transfers in, less expenses and transfers
+// out, in the base currency of the group.
+func (r *repository) GetBalance(gid int64, wid types.ID, asOf types.Timestamp) (balance types.Money, err error) {
+	w, err := r.GetWallet(gid, wid)
+	if err != nil {
+		return
+	}
+	balance = w.OpeningBalance
+
+	for _, m := range []struct {
+		table, wallet, sign string
+	}{
+		{"transactions", "t.wallet_id", "(CASE WHEN c.type = 'INCOME' THEN 1 ELSE -1 END)"},
+		{"transfers", "t.to_wallet_id", "1"},
+		{"transfers", "t.from_wallet_id", "-1"},
+	} {
+		b := SQL.Select().
+			From(m.table + " t").
+			Join("accounts a ON t.account_id = a.id").
+			Join("groups g ON a.group_id = g.id").
+			Where(sq.Eq{m.wallet: wid}).
+			Where(sq.LtOrEq{"t.timestamp": asOf})
+		if m.table == "transactions" {
+			b = b.Join("categories c ON t.category_id = c.id")
+		}
+		if err = checkRates(r.db, b); err != nil {
+			return
+		}
+		var sum types.Money
+		s, args := b.Column("IFNULL(SUM(" + m.sign + " * " + baseAmount + "), 0)").MustSQL()
+		if err = r.db.Get(&sum, s, args...); err != nil {
+			return
+		}
+		balance += sum
+	}
+	return
+}
+
+func (r *repository) CreateTransfer(gid int64, t models.Transfer) (types.ID, error) {
+	if t.FromWalletID == t.ToWalletID {
+		return types.ZeroID, ErrTransferSelf
+	}
+	for _, wid := range []types.ID{t.FromWalletID, t.ToWalletID} {
+		if err := checkWallet(r.db, gid, wid); err != nil {
+			return types.ZeroID, err
+		}
+	}
+	id := types.MakeID()
+	s, args := SQL.Insert("transfers").
+		Columns("id", "account_id", "from_wallet_id", "to_wallet_id", "amount", "currency", "title", "timestamp").
+		Values(id, t.AccountID, t.FromWalletID, t.ToWalletID, t.Amount, t.Currency, t.Title, t.Timestamp).
+		MustSQL()
+	_, err := r.db.Exec(s, args...)
+	return id, err
+}
+
+// ListTransfers lists the transfers of the account, latest first, only those
+// from or to the wallet if given.
+func (r *repository) ListTransfers(aid int64, wid *types.ID) (ts []models.Transfer, err error) {
+	b := SQL.Select("*").
+		From("transfers").
+		Where(sq.Eq{"account_id": aid}).
+		OrderBy("timestamp DESC", "id DESC")
+	if wid != nil {
+		b = b.Where(sq.Or{sq.Eq{"from_wallet_id": *wid}, sq.Eq{"to_wallet_id": *wid}})
+	}
+	s, args := b.MustSQL()
+	err = r.db.Select(&ts, s, args...)
+	return
+}
+
+func (r *repository) DeleteTransfer(aid int64, id types.ID) error {
+	s, args := SQL.Delete("transfers").
+		Where(sq.Eq{"id": id, "account_id": aid}).
+		MustSQL()
+	return affected(r.db.Exec(s, args...))
+}
+
+// tagName trims the name of a tag of spaces and its leading #, and reports
+// ErrTagName unless 1 to 30 characters without spaces are left.
+func tagName(name string) (string, error) {
+	name = strings.TrimPrefix(strings.TrimSpace(name), "#")
+	if n := utf8.RuneCountInString(name); n < 1 || n > 30 || strings.ContainsFunc(name, unicode.IsSpace) {
+		return "", ErrTagName
+	}
+	return name, nil
+}
+
+// findTag returns the tag of the group by name, regardless of case, which is
+// created if missing.
+func findTag(tx *sqlx.Tx, gid int64, name string) (id types.ID, err error) {
+	s, args := SQL.Select("id").
+		From("tags").
+		Where(sq.Eq{"group_id": gid, "name": name}).
+		MustSQL()
+	err = tx.Get(&id, s, args...)
+	if err == ErrNoRows {
+		id = types.MakeID()
+		s, args = SQL.Insert("tags").
+			Columns("id", "group_id", "name").
+			Values(id, gid, name).
+			MustSQL()
+		_, err = tx.Exec(s, args...)
+	}
+	return
+}
+
+func (r *repository) ListTags(gid int64) (tags []models.Tag, err error) {
+	s, args := SQL.Select("*").
+		From("tags").
+		Where(sq.Eq{"group_id": gid}).
+		OrderBy("name").
+		MustSQL()
+	err = r.db.Select(&tags, s, args...)
+	return
+}
+
+func (r *repository) GetTag(gid int64, id types.ID) (tag models.Tag, err error) {
+	s, args := SQL.Select("*").
+		From("tags").
+		Where(sq.Eq{"id": id, "group_id": gid}).
+		MustSQL()
+	err = r.db.Get(&tag, s, args...)
+	return
+}
+
+// DeleteTag deletes the tag and removes it from every transaction.
+func (r *repository) DeleteTag(gid int64, id types.ID) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	s, args := SQL.Delete("tags").
+		Where(sq.Eq{"id": id, "group_id": gid}).
+		MustSQL()
+	if err := affected(tx.Exec(s, args...)); err != nil {
+		return err
+	}
+	s, args = SQL.Delete("transaction_tags").
+		Where(sq.Eq{"tag_id": id}).
+		MustSQL()
+	if _, err := tx.Exec(s, args...); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *repository) ListTransactionTags(aid int64, tid types.ID) (tags []models.Tag, err error) {
+	s, args := SQL.Select("tg.*").
+		From("tags tg").
+		Join("transaction_tags tt ON tt.tag_id = tg.id").
+		Join("transactions t ON tt.transaction_id = t.id").
+		Where(sq.Eq{"t.id": tid, "t.account_id": aid}).
+		OrderBy("tg.name").
+		MustSQL()
+	err = r.db.Select(&tags, s, args...)
+	return
+}
+
+// TagTransaction adds the tags of the names to the transaction, creating the
+// tags the group does not have yet. Tags the transaction has already are
+// left as they are.
+func (r *repository) TagTransaction(gid, aid int64, tid types.ID, names []string) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	s, args := SQL.Select("1").
+		From("transactions").
+		Where(sq.Eq{"id": tid, "account_id": aid}).
+		MustSQL()
+	if err := tx.Get(new(int), s, args...); err != nil {
+		return err
+	}
+	for _, name := range names {
+		name, err := tagName(name)
+		if err != nil {
+			return err
+		}
+		id, err := findTag(tx, gid, name)
+		if err != nil {
+			return err
+		}
+		s, args = SQL.Insert("transaction_tags").
+			Columns("transaction_id", "tag_id").
+			Values(tid, id).
+			Suffix("ON CONFLICT DO NOTHING").
+			MustSQL()
+		if _, err := tx.Exec(s, args...); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// UntagTransaction removes the tags of the names from the transaction. The
+// tags are kept for other transactions, even if none is left.
+func (r *repository) UntagTransaction(gid, aid int64, tid types.ID, names []string) error {
+	s, args := SQL.Select("1").
+		From("transactions").
+		Where(sq.Eq{"id": tid, "account_id": aid}).
+		MustSQL()
+	if err := r.db.Get(new(int), s, args...); err != nil {
+		return err
+	}
+	trimmed := make([]string, len(names))
+	for i, name := range names {
+		var err error
+		if trimmed[i], err = tagName(name); err != nil {
+			return err
+		}
+	}
+	s, args = SQL.Delete("transaction_tags").
+		Where(sq.Eq{"transaction_id": tid}).
+		Where(sq.Expr("tag_id IN (?)", subquery.Select("id").
+			From("tags").
+			Where(sq.Eq{"group_id": gid, "name": trimmed}))).
+		MustSQL()
+	_, err := r.db.Exec(s, args...)
+	return err
+}
+
+func (r *repository) CreateRecurrence(gid int64, rec models.Recurrence) (types.ID, error) {
+	if err := checkCategory(r.db, gid, rec.CategoryID); err != nil {
+		return types.ZeroID, err
+	}
+	if err := checkWallet(r.db, gid, rec.WalletID); err != nil {
+		return types.ZeroID, err
+	}
+	rid := types.MakeID()
+	s, args := SQL.Insert("recurrences").
+		Columns("id", "account_id", "category_id", "wallet_id", "title", "amount", "currency",
+			"frequency", "interval", "start", "until", "count", "next", "next_at").
+		Values(rid, rec.AccountID, rec.CategoryID, rec.WalletID, rec.Title, rec.Amount, rec.Currency,
+			rec.Frequency, rec.Interval, rec.Start, rec.Until, rec.Count, rec.Next, rec.NextAt).
+		MustSQL()
+	_, err := r.db.Exec(s, args...)
+	return rid, err
+}
+
+func (r *repository) GetRecurrence(aid int64, rid types.ID) (rec models.Recurrence, err error) {
+	s, args := SQL.Select("*").
+		From("recurrences").
+		Where(sq.Eq{"id": rid, "account_id": aid}).
+		MustSQL()
+	err = r.db.Get(&rec, s, args...)
+	return
+}
+
+func (r *repository) ListRecurrences(aid int64) (recs []models.Recurrence, err error) {
+	s, args := SQL.Select("*").
+		From("recurrences").
+		Where(sq.Eq{"account_id": aid}).
+		OrderBy("start").
+		MustSQL()
+	err = r.db.Select(&recs, s, args...)
+	return
+}
+
+func (r *repository) DeleteRecurrence(aid int64, rid types.ID) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	s, args := SQL.Delete("recurrences").
+		Where(sq.Eq{"id": rid, "account_id": aid}).
+		MustSQL()
+	if err := affected(tx.Exec(s, args...)); err != nil {
+		return err
+	}
+
+	// keep the transactions already created, but detach them from the rule
+	s, args = SQL.Update("transactions").
+		Set("recurrence_id", nil).
+		Where(sq.Eq{"recurrence_id": rid}).
+		MustSQL()
+	if _, err := tx.Exec(s, args...); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *repository) ListDueRecurrences(now types.Timestamp) (recs []models.Recurrence, err error) {
+	s, args := SQL.Select("*").
+		From("recurrences").
+		Where(sq.LtOrEq{"next_at": now}).
+		MustSQL()
+	err = r.db.Select(&recs, s, args...)
+	return
+}
+
+// CreateOccurrences creates the transactions of occurrences of rec and
+// returns those created, which leaves out occurrences created before.
+func (r *repository) CreateOccurrences(rec models.Recurrence, txns []models.Transaction) (created []models.Transaction, err error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return
+	}
+	defer tx.Rollback()
+
+	for _, t := range txns {
+		t.ID = types.MakeID()
+		// occurrences created before are ignored by the unique index
+		s, args := SQL.Insert("transactions").
+			Columns("id", "account_id", "category_id", "wallet_id", "amount", "timestamp", "title", "currency", "recurrence_id").
+			Values(t.ID, t.AccountID, t.CategoryID, t.WalletID, t.Amount, t.Timestamp, t.Title, t.Currency, t.RecurrenceID).
+			Suffix("ON CONFLICT DO NOTHING").
+			MustSQL()
+		result, err := tx.Exec(s, args...)
+		if err != nil {
+			return nil, err
+		}
+		if n, err := result.RowsAffected(); err != nil {
+			return nil, err
+		} else if n > 0 {
+			created = append(created, t)
+		}
+	}
+
+	s, args := SQL.Update("recurrences").
+		Set("next", rec.Next).
+		Set("next_at", rec.NextAt).
+		Where(sq.Eq{"id": rec.ID}).
+		MustSQL()
+	if _, err = tx.Exec(s, args...); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	return
+}
+
+func (r *repository) CreateNotifications(ns []models.Notification) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, n := range ns {
+		// thresholds alerted before in the same period are ignored by the unique index
+		s, args := SQL.Insert("notifications").
+			Columns("id", "group_id", "category_id", "threshold", "period_start", "amount", "spent", "timestamp").
+			Values(types.MakeID(), n.GroupID, n.CategoryID, n.Threshold, n.PeriodStart, n.Amount, n.Spent, n.Timestamp).
+			Suffix("ON CONFLICT DO NOTHING").
+			MustSQL()
+		if _, err := tx.Exec(s, args...); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *repository) ListNotifications(gid int64, unreadOnly bool) (ns []models.Notification, err error) {
+	b := SQL.Select("*").
+		From("notifications").
+		Where(sq.Eq{"group_id": gid}).
+		OrderBy("timestamp DESC", "id DESC")
+	if unreadOnly {
+		b = b.Where(sq.Eq{"read": false})
+	}
+	s, args := b.MustSQL()
+	err = r.db.Select(&ns, s, args...)
+	return
+}
+
+// MarkNotificationsRead marks the notifications of ids as read, or all of
+// them if ids is nil, and returns the number of notifications marked.
+func (r *repository) MarkNotificationsRead(gid int64, ids []types.ID) (int, error) {
+	b := SQL.Update("notifications").
+		Set("read", true).
+		Where(sq.Eq{"group_id": gid, "read": false})
+	if ids != nil {
+		b = b.Where(sq.Eq{"id": ids})
+	}
+	s, args := b.MustSQL()
+	result, err := r.db.Exec(s, args...)
+	if err != nil {
+		return 0, err
+	}
+	n, err := result.RowsAffected()
+	return int(n), err
+}
+
+// ImportTransactions creates the categories and transactions, which already
+// have their IDs, all at once. Transactions with the same external ID, or
+// without one but with the same date, amount and title as an existing one of
+// the account are reported as duplicates and skipped. Nothing is created for
+// a dry run.
+func (r *repository) ImportTransactions(gid int64, cs []models.Category, txns []models.Transaction, dryRun bool) (duplicates []bool, err error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return
+	}
+	defer tx.Rollback()
+
+	for _, c := range cs {
+		s, args := SQL.Insert("categories").
+			Columns("id", "group_id", "name", "type", "emoji", "color").
+			Values(c.ID, gid, c.Name, c.Type, c.Emoji, c.Color).
+			MustSQL()
+		if _, err = tx.Exec(s, args...); err != nil {
+			return
+		}
+	}
+
+	duplicates = make([]bool, len(txns))
+	for i, t := range txns {
+		// earlier rows of the same import count as existing too
+		b := SQL.Select("COUNT(*) > 0").
+			From("transactions t")
+		if t.ExternalID != nil {
+			b = b.Where(sq.Eq{"t.account_id": t.AccountID, "t.external_id": *t.ExternalID})
+		} else {
+			// an income and an expense of the same amount are told apart
+			// by the type of their categories
+			ct := subquery.Select("type").
+				From("categories").
+				Where(sq.Eq{"id": t.CategoryID})
+			b = b.Join("categories c ON c.id = t.category_id").
+				Where(sq.Eq{"t.account_id": t.AccountID, "t.amount": t.Amount, "t.title": t.Title}).
+				Where("DATE(t.timestamp, 'unixepoch') = DATE(?, 'unixepoch')", t.Timestamp).
+				Where(sq.Expr("c.type = (?)", ct))
+		}
+		s, args := b.MustSQL()
+		if err = tx.Get(&duplicates[i], s, args...); err != nil {
+			return
+		}
+		if duplicates[i] {
+			continue
+		}
+		s, args = SQL.Insert("transactions").
+			Columns("id", "account_id", "category_id", "wallet_id", "amount", "timestamp", "title", "currency", "external_id").
+			Values(t.ID, t.AccountID, t.CategoryID, t.WalletID, t.Amount, t.Timestamp, t.Title, t.Currency, t.ExternalID).
+			MustSQL()
+		if _, err = tx.Exec(s, args...); err != nil {
+			return
+		}
+	}
+
+	if dryRun {
+		return
+	}
+	err = tx.Commit()
+	return
+}
+
+// GetPayeeCategory returns the category of type ct of the latest transaction
+// of the account with the same title, ignoring case.
+func (r *repository) GetPayeeCategory(aid int64, title string, ct models.CategoryType) (cid types.ID, err error) {
+	s, args := SQL.Select("t.category_id").
+		From("transactions t").
+		Join("categories c ON t.category_id = c.id").
+		Where(sq.Eq{"t.account_id": aid, "c.type": ct}).
+		Where("t.title = ? COLLATE NOCASE", title).
+		OrderBy("t.timestamp DESC").
+		Limit(1).
+		MustSQL()
+	err = r.db.Get(&cid, s, args...)
+	return
+}
+
+// CreateMember creates an account in the group of the invitation with its
+// role. The invitation is accepted in place of a license key.
+func (r *repository) CreateMember(a models.Account, code string, now types.Timestamp) (int64, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return -1, err
+	}
+	defer tx.Rollback()
+
+	inv, err := takeInvitation(tx, code, now)
+	if err != nil {
+		return -1, err
+	}
+	s, args := SQL.Insert("accounts").
+		Columns("id", "group_id", "email", "fullname", "passhash", "role").
+		Values(nil, inv.GroupID, a.Email, a.Fullname, a.Passhash, inv.Role).
+		MustSQL()
+	result, err := tx.Exec(s, args...)
+	if err != nil {
+		return -1, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return -1, err
+	}
+	return result.LastInsertId()
+}
+
+func (r *repository) ListMembers(gid int64) (as []models.Account, err error) {
+	s, args := SQL.Select("id", "group_id", "email", "fullname", "role").
+		From("accounts").
+		Where(sq.Eq{"group_id": gid}).
+		OrderBy("id").
+		MustSQL()
+	err = r.db.Select(&as, s, args...)
+	return
+}
+
+// isOwner reports ErrForbidden unless the account is an owner of the group,
+// as roles may have changed since the request was authorized.
+func isOwner(q sqlx.Queryer, gid, aid int64) error {
+	s, args := SQL.Select("1").
+		From("accounts").
+		Where(sq.Eq{"id": aid, "group_id": gid, "role": models.RoleOwner}).
+		MustSQL()
+	err := sqlx.Get(q, new(int), s, args...)
+	if err == ErrNoRows {
+		err = ErrForbidden
+	}
+	return err
+}
+
+// RemoveMember moves member aid out of the group into a new group of its
+// own, as long as owner is still an owner of the group.
+func (r *repository) RemoveMember(gid, owner, aid int64) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := isOwner(tx, gid, owner); err != nil {
+		return err
+	}
+	s, args := SQL.Select("1").
+		From("accounts").
+		Where(sq.Eq{"id": aid, "group_id": gid}).
+		MustSQL()
+	if err := tx.Get(new(int), s, args...); err != nil {
+		return err
+	}
+	if _, err := leaveGroup(tx, aid); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// SetRole changes the role of member aid, as long as owner is still an owner
+// of the group.
+func (r *repository) SetRole(gid, owner, aid int64, role models.Role) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := isOwner(tx, gid, owner); err != nil {
+		return err
+	}
+	s, args := SQL.Update("accounts").
+		Set("role", role).
+		Where(sq.Eq{"id": aid, "group_id": gid}).
+		MustSQL()
+	if err := affected(tx.Exec(s, args...)); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// LeaveGroup moves the account out of its group into a new group of its own,
+// with the same currency, and returns the new group.
+func (r *repository) LeaveGroup(aid int64) (gid int64, err error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return
+	}
+	defer tx.Rollback()
+
+	if gid, err = leaveGroup(tx, aid); err != nil {
+		return
+	}
+
+	err = tx.Commit()
+	return
+}
+
+// leaving returns the number of members of group gid other than account aid,
+// which is about to leave it. It reports ErrLastOwner if aid is the only
+// owner of the members left behind.
+func leaving(tx *sqlx.Tx, aid, gid int64) (others int, err error) {
+	var role models.Role
+	s, args := SQL.Select("role").
+		From("accounts").
+		Where(sq.Eq{"id": aid}).
+		MustSQL()
+	if err = tx.Get(&role, s, args...); err != nil {
+		return
+	}
+	var roles []models.Role
+	s, args = SQL.Select("role").
+		From("accounts").
+		Where(sq.Eq{"group_id": gid}).
+		Where(sq.NotEq{"id": aid}).
+		MustSQL()
+	if err = tx.Select(&roles, s, args...); err != nil {
+		return
+	}
+	if role == models.RoleOwner && len(roles) > 0 && !slices.Contains(roles, models.RoleOwner) {
+		return 0, ErrLastOwner
+	}
+	return len(roles), nil
+}
+
+// leaveGroup moves the account into a new group, unless it is the only
+// member of its group, or the only owner of the members left behind.
+func leaveGroup(tx *sqlx.Tx, aid int64) (gid int64, err error) {
+	var prev models.Group
+	s, args := SQL.Select("g.*").
+		From("groups g").
+		Join("accounts a ON a.group_id = g.id").
+		Where(sq.Eq{"a.id": aid}).
+		MustSQL()
+	if err = tx.Get(&prev, s, args...); err != nil {
+		return
+	}
+	others, err := leaving(tx, aid, prev.ID)
+	if err != nil {
+		return
+	}
+	if others == 0 {
+		return 0, ErrAlone
+	}
+
+	s, args = SQL.Insert("groups").
+		Columns("currency").
+		Values(prev.Currency).
+		MustSQL()
+	result, err := tx.Exec(s, args...)
+	if err != nil {
+		return
+	}
+	if gid, err = result.LastInsertId(); err != nil {
+		return
+	}
+	if _, err = createWallet(tx, models.Wallet{GroupID: gid, Name: models.DefaultWallet}); err != nil {
+		return
+	}
+	err = moveAccount(tx, aid, prev.ID, gid, models.RoleOwner)
+	return
+}
+
+func (r *repository) CreateInvitation(inv models.Invitation) error {
+	s, args := SQL.Insert("invitations").
+		Columns("code", "group_id", "role", "created_by", "created_at", "expires_at").
+		Values(inv.Code, inv.GroupID, inv.Role, inv.CreatedBy, inv.CreatedAt, inv.ExpiresAt).
+		MustSQL()
+	_, err := r.db.Exec(s, args...)
+	return err
+}
+
+// ListInvitations lists the invitations of the group yet to expire.
+func (r *repository) ListInvitations(gid int64, now types.Timestamp) (invs []models.Invitation, err error) {
+	s, args := SQL.Select("*").
+		From("invitations").
+		Where(sq.Eq{"group_id": gid}).
+		Where(sq.Gt{"expires_at": now}).
+		OrderBy("created_at").
+		MustSQL()
+	err = r.db.Select(&invs, s, args...)
+	return
+}
+
+func (r *repository) DeleteInvitation(gid int64, code string) error {
+	s, args := SQL.Delete("invitations").
+		Where(sq.Eq{"code": code, "group_id": gid}).
+		MustSQL()
+	return affected(r.db.Exec(s, args...))
+}
+
+// AcceptInvitation moves the account into the group of the invitation with
+// its role, and returns the group. The transactions and recurrences of the
+// account move along, onto the categories of the same name in the group,
+// which are created where missing.
+func (r *repository) AcceptInvitation(aid int64, code string, now types.Timestamp) (gid int64, err error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return
+	}
+	defer tx.Rollback()
+
+	var prev int64
+	s, args := SQL.Select("group_id").
+		From("accounts").
+		Where(sq.Eq{"id": aid}).
+		MustSQL()
+	if err = tx.Get(&prev, s, args...); err != nil {
+		return
+	}
+	inv, err := takeInvitation(tx, code, now)
+	if err != nil {
+		return
+	}
+	if gid = inv.GroupID; gid == prev {
+		return 0, ErrMember
+	}
+	if _, err = leaving(tx, aid, prev); err != nil {
+		return
+	}
+	if err = moveAccount(tx, aid, prev, gid, inv.Role); err != nil {
+		return
+	}
+
+	err = tx.Commit()
+	return
+}
+
+// takeInvitation deletes the invitation, so that it is accepted only once,
+// and returns it.
+func takeInvitation(tx *sqlx.Tx, code string, now types.Timestamp) (inv models.Invitation, err error) {
+	s, args := SQL.Delete("invitations").
+		Where(sq.Eq{"code": code}).
+		Where(sq.Gt{"expires_at": now}).
+		Suffix("RETURNING *").
+		MustSQL()
+	if err = tx.Get(&inv, s, args...); err == ErrNoRows {
+		err = ErrInvitation
+	}
+	return
+}
+
+// moveAccount moves the account from group prev to group gid with the role.
+// The categories of its transactions, splits and recurrences are replaced by
+// those of the same name and type in group gid, which are copied over if
+// missing. Wallets and tags are replaced likewise by name, see moveWallets
+// and moveTags.
+func moveAccount(tx *sqlx.Tx, aid, prev, gid int64, role models.Role) error {
+	var cs []models.Category
+	s, args := SQL.Select("*").
+		From("categories").
+		Where(sq.Eq{"group_id": prev}).
+		Where(sq.Or{
+			sq.Expr("id IN (SELECT category_id FROM transactions WHERE account_id = ?)", aid),
+			sq.Expr("id IN (SELECT category_id FROM recurrences WHERE account_id = ?)", aid),
+			sq.Expr("id IN (SELECT category_id FROM splits WHERE transaction_id IN (SELECT id FROM transactions WHERE account_id = ?))", aid),
+		}).
+		MustSQL()
+	if err := tx.Select(&cs, s, args...); err != nil {
+		return err
+	}
+
+	for _, c := range cs {
+		var cid types.ID
+		s, args = SQL.Select("id").
+			From("categories").
+			Where(sq.Eq{"group_id": gid, "name": c.Name, "type": c.Type}).
+			MustSQL()
+		err := tx.Get(&cid, s, args...)
+		if err == ErrNoRows {
+			cid = types.MakeID()
+			s, args = SQL.Insert("categories").
+				Columns("id", "group_id", "name", "type", "emoji", "color").
+				Values(cid, gid, c.Name, c.Type, c.Emoji, c.Color).
+				MustSQL()
+			_, err = tx.Exec(s, args...)
+		}
+		if err != nil {
+			return err
+		}
+		for _, table := range []string{"transactions", "recurrences"} {
+			s, args = SQL.Update(table).
+				Set("category_id", cid).
+				Where(sq.Eq{"account_id": aid, "category_id": c.ID}).
+				MustSQL()
+			if _, err := tx.Exec(s, args...); err != nil {
+				return err
+			}
+		}
+		s, args = SQL.Update("splits").
+			Set("category_id", cid).
+			Where(sq.Eq{"category_id": c.ID}).
+			Where(sq.Expr("transaction_id IN (SELECT id FROM transactions WHERE account_id = ?)", aid)).
+			MustSQL()
+		if _, err := tx.Exec(s, args...); err != nil {
+			return err
+		}
+	}
+
+	if err := moveWallets(tx, aid, prev, gid); err != nil {
+		return err
+	}
+	if err := moveTags(tx, aid, prev, gid); err != nil {
+		return err
+	}
+
+	s, args = SQL.Update("accounts").
+		Set("group_id", gid).
+		Set("role", role).
+		Where(sq.Eq{"id": aid}).
+		MustSQL()
+	if _, err := tx.Exec(s, args...); err != nil {
+		return err
+	}
+	return abandonGroup(tx, prev)
+}
+
+// moveWallets replaces the wallets of the transactions, recurrences and
+// transfers of the account in group prev by those of the same name in group
+// gid. Missing wallets are copied over without their opening balance, which
+// stays with the wallet left behind.
+func moveWallets(tx *sqlx.Tx, aid, prev, gid int64) error {
+	var ws []models.Wallet
+	s, args := SQL.Select("*").
+		From("wallets").
+		Where(sq.Eq{"group_id": prev}).
+		Where(sq.Or{
+			sq.Expr("id IN (SELECT wallet_id FROM transactions WHERE account_id = ?)", aid),
+			sq.Expr("id IN (SELECT wallet_id FROM recurrences WHERE account_id = ?)", aid),
+			sq.Expr("id IN (SELECT from_wallet_id FROM transfers WHERE account_id = ?)", aid),
+			sq.Expr("id IN (SELECT to_wallet_id FROM transfers WHERE account_id = ?)", aid),
+		}).
+		MustSQL()
+	if err := tx.Select(&ws, s, args...); err != nil {
+		return err
+	}
+
+	for _, w := range ws {
+		var wid types.ID
+		s, args = SQL.Select("id").
+			From("wallets").
+			Where(sq.Eq{"group_id": gid, "name": w.Name}).
+			MustSQL()
+		err := tx.Get(&wid, s, args...)
+		if err == ErrNoRows {
+			wid, err = createWallet(tx, models.Wallet{GroupID: gid, Name: w.Name})
+		}
+		if err != nil {
+			return err
+		}
+		for _, ref := range []struct{ table, column string }{
+			{"transactions", "wallet_id"},
+			{"recurrences", "wallet_id"},
+			{"transfers", "from_wallet_id"},
+			{"transfers", "to_wallet_id"},
+		} {
+			s, args = SQL.Update(ref.table).
+				Set(ref.column, wid).
+				Where(sq.Eq{"account_id": aid, ref.column: w.ID}).
+				MustSQL()
+			if _, err := tx.Exec(s, args...); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// moveTags replaces the tags of the transactions of the account in group prev
+// by those of the same name in group gid, which are created if missing.
+func moveTags(tx *sqlx.Tx, aid, prev, gid int64) error {
+	transactions := subquery.Select("id").
+		From("transactions").
+		Where(sq.Eq{"account_id": aid})
+	var tags []models.Tag
+	s, args := SQL.Select("*").
+		From("tags").
+		Where(sq.Eq{"group_id": prev}).
+		Where(sq.Expr("id IN (?)", subquery.Select("tag_id").
+			From("transaction_tags").
+			Where(sq.Expr("transaction_id IN (?)", transactions)))).
+		MustSQL()
+	if err := tx.Select(&tags, s, args...); err != nil {
+		return err
+	}
+
+	for _, tag := range tags {
+		id, err := findTag(tx, gid, tag.Name)
+		if err != nil {
+			return err
+		}
+		s, args = SQL.Update("transaction_tags").
+			Set("tag_id", id).
+			Where(sq.Eq{"tag_id": tag.ID}).
+			Where(sq.Expr("transaction_id IN (?)", transactions)).
+			MustSQL()
+		if _, err := tx.Exec(s, args...); err != nil {
+			return err
+		}
+	}
+	return nil
 }
@@ -0,0 +1,643 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"finawise.app/server/models"
+	"finawise.app/server/models/types"
+)
+
+func testRepository(t *testing.T) *repository {
+	t.Helper()
+	db := openTest(t)
+	if _, err := migrate(db); err != nil {
+		t.Fatal(err)
+	}
+	return &repository{db: db, ready: make(chan struct{})}
+}
+
+// testAccount creates an account in a group of its own, returning the ids
+// of both.
+func testAccount(t *testing.T, r *repository, email string) (aid, gid int64) {
+	t.Helper()
+	if _, err := r.db.Exec(`INSERT INTO licensekeys (key) VALUES ($1)`, email); err != nil {
+		t.Fatal(err)
+	}
+	aid, err := r.CreateAccount(models.Account{Email: email, Fullname: email}, email)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, err := r.GetAccount(aid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return aid, a.GroupID
+}
+
+func testCategory(t *testing.T, r *repository, gid int64, name string, typ models.CategoryType) types.ID {
+	t.Helper()
+	cid, err := r.CreateCategory(models.Category{GroupID: gid, Name: name, Type: typ, Emoji: "📦", Color: "#000000"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cid
+}
+
+// testTransaction creates a transaction in the group currency and default
+// wallet, at the given day of January 2024.
+func testTransaction(t *testing.T, r *repository, aid, gid int64, cid types.ID, amount types.Money, day int) types.ID {
+	t.Helper()
+	g, err := r.GetGroup(gid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := r.GetWallet(gid, types.ZeroID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tid, err := r.CreateTransaction(gid, models.Transaction{
+		AccountID:  aid,
+		CategoryID: cid,
+		WalletID:   w.ID,
+		Title:      fmt.Sprintf("t%d", day),
+		Amount:     amount,
+		Timestamp:  types.Timestamp{Time: time.Date(2024, time.January, day, 12, 0, 0, 0, time.UTC)},
+		Currency:   g.Currency,
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tid
+}
+
+func TestDeleteCategory(t *testing.T) {
+	r := testRepository(t)
+	aid, gid := testAccount(t, r, "a@x.io")
+	used := testCategory(t, r, gid, "Food", models.CategoryTypeExpense)
+	unused := testCategory(t, r, gid, "Travel", models.CategoryTypeExpense)
+	other := testCategory(t, r, gid, "Other", models.CategoryTypeExpense)
+	tid := testTransaction(t, r, aid, gid, used, 1000, 1)
+
+	if err := r.DeleteCategory(gid, used, nil); !errors.Is(err, ErrCategoryUsed) {
+		t.Fatalf("DeleteCategory(used) = %v, want %v", err, ErrCategoryUsed)
+	}
+	if _, err := r.GetCategory(gid, used); err != nil {
+		t.Fatalf("used category gone: %v", err)
+	}
+
+	if err := r.DeleteCategory(gid, unused, nil); err != nil {
+		t.Fatalf("DeleteCategory(unused) = %v", err)
+	}
+	if _, err := r.GetCategory(gid, unused); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("unused category: %v, want %v", err, sql.ErrNoRows)
+	}
+
+	if err := r.DeleteCategory(gid, used, &other); err != nil {
+		t.Fatalf("DeleteCategory(used, other) = %v", err)
+	}
+	tr, err := r.GetTransaction(aid, tid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tr.CategoryID != other {
+		t.Errorf("transaction category = %v, want %v", tr.CategoryID, other)
+	}
+}
+
+func TestMergeCategories(t *testing.T) {
+	r := testRepository(t)
+	aid, gid := testAccount(t, r, "a@x.io")
+	a := testCategory(t, r, gid, "A", models.CategoryTypeExpense)
+	b := testCategory(t, r, gid, "B", models.CategoryTypeExpense)
+	c := testCategory(t, r, gid, "C", models.CategoryTypeExpense)
+	target := testCategory(t, r, gid, "Target", models.CategoryTypeExpense)
+	testTransaction(t, r, aid, gid, a, 1000, 1)
+	testTransaction(t, r, aid, gid, b, 2000, 2)
+
+	budgets := []models.Budget{
+		{CategoryID: a, Amount: 10000, Period: models.BudgetPeriodMonthly},
+		{CategoryID: b, Amount: 5000, Period: models.BudgetPeriodMonthly},
+		{CategoryID: c, Amount: 5000, Period: models.BudgetPeriodWeekly},
+		{CategoryID: target, Amount: 20000, Period: models.BudgetPeriodMonthly},
+	}
+	for _, b := range budgets {
+		b.Anchor = b.Period.DefaultAnchor()
+		if err := r.SetBudget(gid, b); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := r.MergeCategories(gid, []types.ID{c}, target); !errors.Is(err, ErrBudgetPeriod) {
+		t.Fatalf("MergeCategories(weekly) = %v, want %v", err, ErrBudgetPeriod)
+	}
+	if _, err := r.GetCategory(gid, c); err != nil {
+		t.Fatalf("category of refused merge gone: %v", err)
+	}
+
+	// a source listed twice is merged once
+	if err := r.MergeCategories(gid, []types.ID{a, b, a}, target); err != nil {
+		t.Fatalf("MergeCategories = %v", err)
+	}
+	for _, cid := range []types.ID{a, b} {
+		if _, err := r.GetCategory(gid, cid); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("source %v: %v, want %v", cid, err, sql.ErrNoRows)
+		}
+	}
+	budget, err := r.GetBudget(gid, target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if budget.Amount != 35000 {
+		t.Errorf("budget = %v, want %v", budget.Amount, types.Money(35000))
+	}
+	conn, err := r.ListTransactions(aid, &target, models.TransactionFilter{}, models.Page{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(conn.Edges) != 2 {
+		t.Errorf("transactions in target = %d, want 2", len(conn.Edges))
+	}
+}
+
+func TestGetTimeSeries(t *testing.T) {
+	r := testRepository(t)
+	aid, gid := testAccount(t, r, "a@x.io")
+	food := testCategory(t, r, gid, "Food", models.CategoryTypeExpense)
+	testCategory(t, r, gid, "Salary", models.CategoryTypeIncome)
+	testTransaction(t, r, aid, gid, food, 1000, 2)
+
+	day := func(d int) types.Timestamp {
+		return types.Timestamp{Time: time.Date(2024, time.January, d, 0, 0, 0, 0, time.UTC)}
+	}
+	tests := []struct {
+		name     string
+		from, to int
+		group    models.TimeSeriesGroup
+		series   int
+		points   int
+		total    types.Money
+	}{
+		{"by type", 1, 4, models.TimeSeriesGroupType, 2, 3, 1000},
+		{"by category", 1, 4, models.TimeSeriesGroupCategory, 2, 3, 1000},
+		{"empty range", 4, 4, models.TimeSeriesGroupType, 2, 0, 0},
+		{"without transactions", 10, 12, models.TimeSeriesGroupCategory, 2, 2, 0},
+	}
+	for _, tt := range tests {
+		series, err := r.GetTimeSeries(aid, day(tt.from), day(tt.to), models.IntervalDay, tt.group)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if len(series) != tt.series {
+			t.Fatalf("%s: %d series, want %d", tt.name, len(series), tt.series)
+		}
+		var total types.Money
+		for _, s := range series {
+			if len(s.Points) != tt.points {
+				t.Errorf("%s: %s series of %d points, want %d", tt.name, s.Type, len(s.Points), tt.points)
+			}
+			if (s.CategoryID != nil) != (tt.group == models.TimeSeriesGroupCategory) {
+				t.Errorf("%s: %s series of category %v", tt.name, s.Type, s.CategoryID)
+			}
+			for _, p := range s.Points {
+				total += p.Amount
+			}
+		}
+		if total != tt.total {
+			t.Errorf("%s: total %v, want %v", tt.name, total, tt.total)
+		}
+	}
+}
+
+func TestListTransactionsPages(t *testing.T) {
+	r := testRepository(t)
+	aid, gid := testAccount(t, r, "a@x.io")
+	cid := testCategory(t, r, gid, "Food", models.CategoryTypeExpense)
+	// latest first, and transactions sharing a timestamp by id
+	days := []int{3, 2, 2, 1}
+	ids := make([]types.ID, len(days))
+	for i, day := range days {
+		ids[i] = testTransaction(t, r, aid, gid, cid, 1000, day)
+	}
+	if ids[1].Compare(ids[2].ULID) < 0 {
+		ids[1], ids[2] = ids[2], ids[1]
+	}
+
+	size := 2
+	var got []types.ID
+	var conn models.TransactionConnection
+	for pages := 1; pages == 1 || conn.PageInfo.HasNextPage; pages++ {
+		if pages > 2 {
+			t.Fatalf("more than 2 pages")
+		}
+		var err error
+		conn, err = r.ListTransactions(aid, nil, models.TransactionFilter{}, models.Page{First: &size, After: conn.PageInfo.EndCursor})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if conn.TotalCount != len(ids) || conn.PageInfo.HasPreviousPage != (pages > 1) {
+			t.Errorf("page %d: TotalCount = %d, PageInfo = %+v", pages, conn.TotalCount, conn.PageInfo)
+		}
+		for _, e := range conn.Edges {
+			got = append(got, e.Node.ID)
+		}
+	}
+	if !slices.Equal(got, ids) {
+		t.Errorf("forwards = %v, want %v", got, ids)
+	}
+
+	// backwards from the start of the last page
+	conn, err := r.ListTransactions(aid, nil, models.TransactionFilter{}, models.Page{Last: &size, Before: conn.PageInfo.StartCursor})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got = nil
+	for _, e := range conn.Edges {
+		got = append(got, e.Node.ID)
+	}
+	if !slices.Equal(got, ids[:2]) || conn.PageInfo.HasPreviousPage || !conn.PageInfo.HasNextPage {
+		t.Errorf("backwards = %v %+v, want %v", got, conn.PageInfo, ids[:2])
+	}
+}
+
+func TestListTransactionsFilter(t *testing.T) {
+	r := testRepository(t)
+	aid, gid := testAccount(t, r, "a@x.io")
+	food := testCategory(t, r, gid, "Food", models.CategoryTypeExpense)
+	travel := testCategory(t, r, gid, "Travel", models.CategoryTypeExpense)
+	salary := testCategory(t, r, gid, "Salary", models.CategoryTypeIncome)
+	ids := []types.ID{
+		testTransaction(t, r, aid, gid, food, 1000, 1),
+		testTransaction(t, r, aid, gid, food, 5000, 2),
+		testTransaction(t, r, aid, gid, salary, 300000, 3),
+		testTransaction(t, r, aid, gid, travel, 2000, 4),
+	}
+
+	day := func(d int) *types.Timestamp {
+		return &types.Timestamp{Time: time.Date(2024, time.January, d, 0, 0, 0, 0, time.UTC)}
+	}
+	money := func(m types.Money) *types.Money { return &m }
+	title := "T3"
+	expense, income := models.CategoryTypeExpense, models.CategoryTypeIncome
+	tests := []struct {
+		name   string
+		filter models.TransactionFilter
+		want   []int // indices into ids, in order
+	}{
+		{"none", models.TransactionFilter{}, []int{3, 2, 1, 0}},
+		{"ascending", models.TransactionFilter{Order: models.SortOrderAsc}, []int{0, 1, 2, 3}},
+		{"dates", models.TransactionFilter{From: day(2), To: day(4)}, []int{2, 1}},
+		{"amounts", models.TransactionFilter{MinAmount: money(1500), MaxAmount: money(6000)}, []int{3, 1}},
+		{"category and amount", models.TransactionFilter{Categories: []types.ID{food}, MinAmount: money(2000)}, []int{1}},
+		{"categories", models.TransactionFilter{Categories: []types.ID{food, travel}, To: day(4)}, []int{1, 0}},
+		{"type and date", models.TransactionFilter{Type: &expense, From: day(2)}, []int{3, 1}},
+		{"title", models.TransactionFilter{Title: &title}, []int{2}},
+		{"type and category", models.TransactionFilter{Type: &income, Categories: []types.ID{food}}, nil},
+	}
+	for _, tt := range tests {
+		conn, err := r.ListTransactions(aid, nil, tt.filter, models.Page{})
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		var got, want []types.ID
+		for _, e := range conn.Edges {
+			got = append(got, e.Node.ID)
+		}
+		for _, i := range tt.want {
+			want = append(want, ids[i])
+		}
+		if !slices.Equal(got, want) || conn.TotalCount != len(want) {
+			t.Errorf("%s = %v (%d in total), want %v", tt.name, got, conn.TotalCount, want)
+		}
+	}
+}
+
+func TestScoping(t *testing.T) {
+	r := testRepository(t)
+	aid, gid := testAccount(t, r, "a@x.io")
+	other, ogid := testAccount(t, r, "b@x.io")
+	cid := testCategory(t, r, gid, "Food", models.CategoryTypeExpense)
+	ocid := testCategory(t, r, ogid, "Food", models.CategoryTypeExpense)
+	tid := testTransaction(t, r, aid, gid, cid, 1000, 1)
+	if err := r.SetBudget(gid, models.Budget{CategoryID: cid, Amount: 10000, Period: models.BudgetPeriodMonthly}); err != nil {
+		t.Fatal(err)
+	}
+	w, err := r.GetWallet(ogid, types.ZeroID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	txn, err := r.GetTransaction(aid, tid)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		err  error
+	}{
+		{"GetTransaction", func() error { _, err := r.GetTransaction(other, tid); return err }()},
+		{"GetCategory", func() error { _, err := r.GetCategory(ogid, cid); return err }()},
+		{"GetBudget", func() error { _, err := r.GetBudget(ogid, cid); return err }()},
+		{"SetBudget", r.SetBudget(ogid, models.Budget{CategoryID: cid, Amount: 1, Period: models.BudgetPeriodMonthly})},
+		{"DeleteTransaction", r.DeleteTransaction(other, tid)},
+		{"DeleteCategory", r.DeleteCategory(ogid, cid, nil)},
+		{"UpdateTransaction of another account", func() error {
+			u := txn
+			u.AccountID, u.CategoryID, u.WalletID = other, ocid, w.ID
+			return r.UpdateTransaction(ogid, u)
+		}()},
+		{"UpdateTransaction into another group", func() error {
+			u := txn
+			u.CategoryID = ocid
+			return r.UpdateTransaction(gid, u)
+		}()},
+		{"CreateTransaction into another group", func() error {
+			u := txn
+			u.AccountID = other
+			_, err := r.CreateTransaction(ogid, u, nil)
+			return err
+		}()},
+	}
+	for _, tt := range tests {
+		if !errors.Is(tt.err, sql.ErrNoRows) {
+			t.Errorf("%s = %v, want %v", tt.name, tt.err, sql.ErrNoRows)
+		}
+	}
+
+	// none of the above changed anything
+	got, err := r.GetTransaction(aid, tid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != txn {
+		t.Errorf("transaction = %+v, want %+v", got, txn)
+	}
+	if b, err := r.GetBudget(gid, cid); err != nil || b.Amount != 10000 {
+		t.Errorf("budget = %v, %v", b.Amount, err)
+	}
+}
+
+func TestImportTransactions(t *testing.T) {
+	r := testRepository(t)
+	aid, gid := testAccount(t, r, "a@x.io")
+	food := testCategory(t, r, gid, "Food", models.CategoryTypeExpense)
+	salary := testCategory(t, r, gid, "Salary", models.CategoryTypeIncome)
+	tid := testTransaction(t, r, aid, gid, food, 1000, 1)
+	existing, err := r.GetTransaction(aid, tid)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	row := func(cid types.ID, day, hour int, external string) models.Transaction {
+		tr := existing
+		tr.ID, tr.CategoryID = types.MakeID(), cid
+		tr.Timestamp = types.Timestamp{Time: time.Date(2024, time.January, day, hour, 0, 0, 0, time.UTC)}
+		if external != "" {
+			tr.ExternalID = &external
+		}
+		return tr
+	}
+	txns := []models.Transaction{
+		row(food, 1, 8, ""),   // same day
+		row(salary, 1, 8, ""), // an income of the same amount
+		row(food, 2, 8, ""),
+		row(food, 2, 9, ""), // same day as the row before
+		row(food, 1, 8, "X1"),
+		row(food, 3, 8, "X1"),
+	}
+	want := []bool{true, false, false, true, false, true}
+
+	// nothing is created on a dry run, and the rows not duplicated otherwise
+	for _, tt := range []struct {
+		dryRun bool
+		count  int
+	}{{true, 1}, {false, 4}} {
+		dryRun := tt.dryRun
+		duplicates, err := r.ImportTransactions(gid, nil, txns, dryRun)
+		if err != nil {
+			t.Fatalf("dry run %v: %v", dryRun, err)
+		}
+		if !slices.Equal(duplicates, want) {
+			t.Errorf("dry run %v: duplicates = %v, want %v", dryRun, duplicates, want)
+		}
+		conn, err := r.ListTransactions(aid, nil, models.TransactionFilter{}, models.Page{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if conn.TotalCount != tt.count {
+			t.Errorf("dry run %v: %d transactions, want %d", dryRun, conn.TotalCount, tt.count)
+		}
+	}
+}
+
+func TestAcceptInvitationAndRemoveMember(t *testing.T) {
+	r := testRepository(t)
+	owner, gid := testAccount(t, r, "a@x.io")
+	aid, prev := testAccount(t, r, "b@x.io")
+	food := testCategory(t, r, gid, "Food", models.CategoryTypeExpense)
+	prevFood := testCategory(t, r, prev, "Food", models.CategoryTypeExpense)
+	rent := testCategory(t, r, prev, "Rent", models.CategoryTypeExpense)
+	tid := testTransaction(t, r, aid, prev, rent, 1000, 1)
+	if err := r.TagTransaction(prev, aid, tid, []string{"home"}); err != nil {
+		t.Fatal(err)
+	}
+	fid := testTransaction(t, r, aid, prev, prevFood, 2000, 2)
+
+	now := types.Timestamp{Time: time.Now()}
+	inv := models.Invitation{Code: "code", GroupID: gid, Role: models.RoleEditor, CreatedBy: owner,
+		CreatedAt: now, ExpiresAt: types.Timestamp{Time: now.Add(time.Hour)}}
+	if err := r.CreateInvitation(inv); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := r.AcceptInvitation(aid, inv.Code, now); err != nil || got != gid {
+		t.Fatalf("AcceptInvitation = %d, %v, want %d", got, err, gid)
+	}
+	if _, err := r.AcceptInvitation(aid, inv.Code, now); !errors.Is(err, ErrInvitation) {
+		t.Errorf("AcceptInvitation again = %v, want %v", err, ErrInvitation)
+	}
+
+	// the transactions move onto the categories, wallet and tags of the
+	// same name in the group, which are created where missing
+	checkMoved(t, r, aid, gid, tid, "Rent", "home")
+	if txn, err := r.GetTransaction(aid, fid); err != nil || txn.CategoryID != food {
+		t.Errorf("transaction in Food = %v, %v, want category %v", txn.CategoryID, err, food)
+	}
+	if a, err := r.GetAccount(aid); err != nil || a.Role != models.RoleEditor {
+		t.Errorf("role = %v, %v, want %v", a.Role, err, models.RoleEditor)
+	}
+
+	if err := r.RemoveMember(gid, aid, owner); !errors.Is(err, ErrForbidden) {
+		t.Errorf("RemoveMember by an editor = %v, want %v", err, ErrForbidden)
+	}
+	if err := r.RemoveMember(gid, owner, aid); err != nil {
+		t.Fatalf("RemoveMember = %v", err)
+	}
+	a, err := r.GetAccount(aid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.GroupID == gid || a.GroupID == prev || a.Role != models.RoleOwner {
+		t.Fatalf("removed member in group %d as %s", a.GroupID, a.Role)
+	}
+	checkMoved(t, r, aid, a.GroupID, tid, "Rent", "home")
+	if _, err := r.GetCategory(gid, food); err != nil {
+		t.Errorf("category left behind: %v", err)
+	}
+}
+
+// checkMoved checks that transaction tid is in a category, wallet and tag of
+// group gid.
+func checkMoved(t *testing.T, r *repository, aid, gid int64, tid types.ID, category, tag string) {
+	t.Helper()
+	txn, err := r.GetTransaction(aid, tid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c, err := r.GetCategory(gid, txn.CategoryID); err != nil || c.Name != category {
+		t.Errorf("category = %q, %v, want %q of group %d", c.Name, err, category, gid)
+	}
+	if _, err := r.GetWallet(gid, txn.WalletID); err != nil {
+		t.Errorf("wallet not of group %d: %v", gid, err)
+	}
+	tags, err := r.ListTransactionTags(aid, tid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tags) != 1 || tags[0].Name != tag || tags[0].GroupID != gid {
+		t.Errorf("tags = %+v, want %q of group %d", tags, tag, gid)
+	}
+}
+
+func TestSplitTotals(t *testing.T) {
+	r := testRepository(t)
+	aid, gid := testAccount(t, r, "a@x.io")
+	food := testCategory(t, r, gid, "Food", models.CategoryTypeExpense)
+	rent := testCategory(t, r, gid, "Rent", models.CategoryTypeExpense)
+	salary := testCategory(t, r, gid, "Salary", models.CategoryTypeIncome)
+	plain, err := r.GetTransaction(aid, testTransaction(t, r, aid, gid, food, 500, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	split := plain
+	split.Amount = 1000
+	if _, err := r.CreateTransaction(gid, split, []models.Split{{CategoryID: food, Amount: 600}, {CategoryID: rent, Amount: 300}}); !errors.Is(err, ErrSplitSum) {
+		t.Errorf("CreateTransaction of splits short of the amount = %v, want %v", err, ErrSplitSum)
+	}
+	if _, err := r.CreateTransaction(gid, split, []models.Split{{CategoryID: food, Amount: 700}, {CategoryID: salary, Amount: 300}}); !errors.Is(err, ErrCategoryType) {
+		t.Errorf("CreateTransaction of an income and expense split = %v, want %v", err, ErrCategoryType)
+	}
+	tid, err := r.CreateTransaction(gid, split, []models.Split{{CategoryID: food, Amount: 700}, {CategoryID: rent, Amount: 300}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// splits count towards their own categories, once each
+	cs, err := r.GetCategorySummaries(aid, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []models.CategorySummary{
+		{CategoryID: food, Type: models.CategoryTypeExpense, Total: 1200, Count: 2},
+		{CategoryID: rent, Type: models.CategoryTypeExpense, Total: 300, Count: 1},
+	}
+	if len(cs) != len(want) {
+		t.Fatalf("GetCategorySummaries = %+v, want %+v", cs, want)
+	}
+	for i, w := range want {
+		w.Share = float64(w.Total) / 1500
+		if cs[i] != w {
+			t.Errorf("GetCategorySummaries[%d] = %+v, want %+v", i, cs[i], w)
+		}
+	}
+	as, err := r.GetAccountSummary(aid, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if as.Expense != 1500 || as.Income != 0 {
+		t.Errorf("GetAccountSummary = %v expense, %v income, want 1500 expense", as.Expense, as.Income)
+	}
+	from := types.Timestamp{Time: time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)}
+	to := types.Timestamp{Time: from.AddDate(0, 1, 0)}
+	if spent, err := r.GetSpending(gid, rent, from, to); err != nil || spent != 300 {
+		t.Errorf("GetSpending(rent) = %v, %v, want 300", spent, err)
+	}
+
+	// yet the transaction is listed once, under any of its categories
+	conn, err := r.ListTransactions(aid, &rent, models.TransactionFilter{}, models.Page{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(conn.Edges) != 1 || conn.Edges[0].Node.ID != tid || conn.Edges[0].Node.CategoryID != food {
+		t.Errorf("ListTransactions(rent) = %+v, want %v in the category of its largest split", conn.Edges, tid)
+	}
+}
+
+func TestTagMatch(t *testing.T) {
+	r := testRepository(t)
+	aid, gid := testAccount(t, r, "a@x.io")
+	cid := testCategory(t, r, gid, "Food", models.CategoryTypeExpense)
+	var ids []types.ID
+	for i, names := range [][]string{{"a"}, {"a", "b"}, {"b"}, nil} {
+		tid := testTransaction(t, r, aid, gid, cid, 1000, i+1)
+		if err := r.TagTransaction(gid, aid, tid, names); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, tid)
+	}
+	tags, err := r.ListTags(gid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tag := map[string]types.ID{}
+	for _, tg := range tags {
+		tag[tg.Name] = tg.ID
+	}
+	a, b := tag["a"], tag["b"]
+
+	tests := []struct {
+		tags  []types.ID
+		match models.TagMatch
+		want  []int // indices into ids, latest first
+	}{
+		{[]types.ID{a}, models.TagMatchAny, []int{1, 0}},
+		{[]types.ID{a, b}, models.TagMatchAny, []int{2, 1, 0}},
+		{[]types.ID{a, b}, models.TagMatchAll, []int{1}},
+		{[]types.ID{a, a}, models.TagMatchAll, []int{1, 0}},
+		{[]types.ID{a, b, a}, models.TagMatchAll, []int{1}},
+	}
+	for _, tt := range tests {
+		conn, err := r.ListTransactions(aid, nil, models.TransactionFilter{Tags: tt.tags, TagMatch: tt.match}, models.Page{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got, want []types.ID
+		for _, e := range conn.Edges {
+			got = append(got, e.Node.ID)
+		}
+		for _, i := range tt.want {
+			want = append(want, ids[i])
+		}
+		if !slices.Equal(got, want) {
+			t.Errorf("%s of %v = %v, want %v", tt.match, tt.tags, got, want)
+		}
+	}
+
+	// transactions count towards each of their tags
+	ts, err := r.GetTagSummaries(aid, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ts) != 2 {
+		t.Fatalf("GetTagSummaries = %+v, want 2 tags", ts)
+	}
+	for _, s := range ts {
+		if s.Count != 2 || s.Expense != 2000 || s.Income != 0 {
+			t.Errorf("GetTagSummaries = %+v, want 2 transactions of 2000", s)
+		}
+	}
+}
@@ -1,3 +1,6 @@
+-- The schema of databases created before migrations existed, with amounts
+-- stored as REAL, which the initial migration adopts.
+
 CREATE TABLE IF NOT EXISTS "licensekeys" (
     "key" TEXT PRIMARY KEY
 );
@@ -1,18 +1,31 @@
 package account
 
 import (
+	"crypto/rand"
+	"encoding/base32"
+	"encoding/json"
 	"fmt"
+	"io"
+	"time"
 
 	"golang.org/x/crypto/bcrypt"
 
 	"finawise.app/server/models"
+	"finawise.app/server/models/types"
 	"finawise.app/server/repository"
 )
 
+// InvitationTTL is how long an invitation can be accepted for.
+const InvitationTTL = 7 * 24 * time.Hour
+
 var (
 	ErrNotFound   = repository.ErrNoRows
 	ErrPassword   = bcrypt.ErrMismatchedHashAndPassword
 	ErrLicenseKey = fmt.Errorf("invalid license key")
+	ErrArchive    = fmt.Errorf("invalid archive")
+	ErrInvitation = repository.ErrInvitation
+	ErrForbidden  = repository.ErrForbidden
+	ErrSelf       = fmt.Errorf("owner cannot remove itself or change its own role, but can leave the group")
 )
 
 type Service struct {
@@ -23,7 +36,9 @@ func NewService(repo repository.Repository) *Service {
 	return &Service{repo: repo}
 }
 
-func (s *Service) Register(email, password, fullname, key string) (a models.Account, err error) {
+// Register creates an account with either a license key, in a new group of
+// its own, or an invitation code, in the group of the invitation.
+func (s *Service) Register(email, password, fullname, key, code string) (a models.Account, err error) {
 	passhash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	if err != nil {
 		return
@@ -32,6 +47,14 @@ func (s *Service) Register(email, password, fullname, key string) (a models.Acco
 		Email:    email,
 		Fullname: fullname,
 		Passhash: string(passhash),
+		Role:     models.RoleOwner,
+	}
+	if code != "" {
+		var id int64
+		if id, err = s.repo.CreateMember(a, code, types.Timestamp{Time: time.Now()}); err != nil {
+			return
+		}
+		return s.repo.GetAccount(id)
 	}
 	id, err := s.repo.CreateAccount(a, key)
 	if err != nil {
@@ -55,3 +78,65 @@ func (s *Service) Login(email, password string) (models.Account, error) {
 		[]byte(password),
 	)
 }
+
+// Delete deletes the account after confirming its password, along with its
+// group if no other member is left.
+func (s *Service) Delete(aid int64, password string) error {
+	a, err := s.repo.GetAccount(aid)
+	if err != nil {
+		return err
+	}
+	if _, err := s.Login(a.Email, password); err != nil {
+		return err
+	}
+	return s.repo.DeleteAccount(aid)
+}
+
+// Export returns an archive of everything in the group.
+func (s *Service) Export(gid int64) (models.Archive, error) {
+	return s.repo.ExportGroup(gid)
+}
+
+// Import restores the archive read from r into a new group of the account,
+// and returns the account as moved into the group.
+func (s *Service) Import(aid int64, r io.Reader) (a models.Account, err error) {
+	var archive models.Archive
+	if err = json.NewDecoder(r).Decode(&archive); err != nil {
+		return a, fmt.Errorf("%w: %w", ErrArchive, err)
+	}
+	if archive.Version < 1 || archive.Version > models.ArchiveVersion {
+		return a, fmt.Errorf("%w: unsupported version %d", ErrArchive, archive.Version)
+	}
+	if _, err = s.repo.ImportGroup(aid, archive); err != nil {
+		if err == repository.ErrArchive {
+			err = fmt.Errorf("%w: %w", ErrArchive, err)
+		}
+		return
+	}
+	return s.repo.GetAccount(aid)
+}
+
+// Invite creates an invitation to the group of the session, for an account
+// to join with the role.
+func (s *Service) Invite(session Session, role models.Role) (inv models.Invitation, err error) {
+	b := make([]byte, 10)
+	if _, err = rand.Read(b); err != nil {
+		return
+	}
+	now := time.Now()
+	inv = models.Invitation{
+		Code:      base32.StdEncoding.EncodeToString(b),
+		GroupID:   session.GroupID,
+		Role:      role,
+		CreatedBy: session.AccountID,
+		CreatedAt: types.Timestamp{Time: now},
+		ExpiresAt: types.Timestamp{Time: now.Add(InvitationTTL)},
+	}
+	err = s.repo.CreateInvitation(inv)
+	return
+}
+
+// Join moves the account into the group of the invitation code.
+func (s *Service) Join(aid int64, code string) (int64, error) {
+	return s.repo.AcceptInvitation(aid, code, types.Timestamp{Time: time.Now()})
+}
@@ -1,6 +1,9 @@
 package account
 
+import "finawise.app/server/models"
+
 type Session struct {
-	AccountID int64 `json:"aid"`
-	GroupID   int64 `json:"gid"`
+	AccountID int64       `json:"aid"`
+	GroupID   int64       `json:"gid"`
+	Role      models.Role `json:"role"`
 }
@@ -0,0 +1,83 @@
+package alerts
+
+import (
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"finawise.app/server/models"
+	"finawise.app/server/models/types"
+	"finawise.app/server/repository"
+)
+
+// Service notifies groups of the budget thresholds reached by their
+// spending, whether the transactions are created by members or by the
+// scheduler.
+type Service struct {
+	repo repository.Repository
+}
+
+func NewService(repo repository.Repository) *Service {
+	return &Service{repo: repo}
+}
+
+// Spending computes the spending of the budget in the period containing t.
+func (s *Service) Spending(gid int64, b models.Budget, t time.Time) (sp models.BudgetSpending, err error) {
+	start, end := b.Period.Bounds(b.Anchor.Time, t)
+	sp = models.BudgetSpending{
+		PeriodStart: types.Timestamp{Time: start},
+		PeriodEnd:   types.Timestamp{Time: end},
+		Amount:      b.Amount,
+	}
+	sp.Spent, err = s.repo.GetSpending(gid, b.CategoryID, sp.PeriodStart, sp.PeriodEnd)
+	return
+}
+
+// Transaction alerts the budget thresholds of the categories that the
+// transaction counts towards: those of its splits if any, or else its own.
+func (s *Service) Transaction(gid int64, t models.Transaction, splits []models.Split) {
+	if len(splits) == 0 {
+		s.thresholds(gid, t.CategoryID, t.Timestamp.Time)
+		return
+	}
+	alerted := make(map[types.ID]bool)
+	for _, sp := range splits {
+		if !alerted[sp.CategoryID] {
+			alerted[sp.CategoryID] = true
+			s.thresholds(gid, sp.CategoryID, t.Timestamp.Time)
+		}
+	}
+}
+
+// thresholds notifies the group of the budget thresholds reached by the
+// spending of category cid in the period containing t. Thresholds alerted
+// before in the same period are not alerted again.
+func (s *Service) thresholds(gid int64, cid types.ID, t time.Time) {
+	b, err := s.repo.GetBudget(gid, cid)
+	if err == repository.ErrNoRows {
+		return
+	}
+	var sp models.BudgetSpending
+	if err == nil {
+		sp, err = s.Spending(gid, b, t)
+	}
+	if err == nil {
+		var ns []models.Notification
+		for _, threshold := range b.Thresholds.Crossed(sp.Spent, sp.Amount) {
+			ns = append(ns, models.Notification{
+				GroupID:     gid,
+				CategoryID:  cid,
+				Threshold:   threshold,
+				PeriodStart: sp.PeriodStart,
+				Amount:      sp.Amount,
+				Spent:       sp.Spent,
+				Timestamp:   types.Timestamp{Time: time.Now()},
+			})
+		}
+		err = s.repo.CreateNotifications(ns)
+	}
+	if err != nil {
+		// the transaction has been created regardless
+		log.Error().Str("from", "alerts").Msg(err.Error())
+	}
+}
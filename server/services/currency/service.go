@@ -0,0 +1,122 @@
+package currency
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"finawise.app/server/models"
+	"finawise.app/server/repository"
+)
+
+var (
+	ErrCurrency    = fmt.Errorf("invalid currency code")
+	ErrUnsupported = fmt.Errorf("currencies without two decimal places are not supported")
+	ErrRatesCSV    = fmt.Errorf("invalid exchange rates csv")
+)
+
+var code = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// Valid reports whether c looks like an ISO 4217 currency code.
+func Valid(c string) bool {
+	return code.MatchString(c)
+}
+
+// unsupported lists the ISO 4217 codes whose minor unit is not a hundredth,
+// or which have none.
+var unsupported = map[string]bool{
+	// no decimal places
+	"BIF": true, "CLP": true, "DJF": true, "GNF": true, "ISK": true, "JPY": true,
+	"KMF": true, "KRW": true, "PYG": true, "RWF": true, "UGX": true, "UYI": true,
+	"VND": true, "VUV": true, "XAF": true, "XOF": true, "XPF": true,
+	// three or four
+	"BHD": true, "IQD": true, "JOD": true, "KWD": true, "LYD": true, "OMR": true,
+	"TND": true, "CLF": true, "UYW": true,
+	// metals and other units
+	"XAG": true, "XAU": true, "XBA": true, "XBB": true, "XBC": true, "XBD": true,
+	"XDR": true, "XPD": true, "XPT": true, "XSU": true, "XTS": true, "XUA": true,
+	"XXX": true,
+}
+
+// Check reports ErrCurrency unless c looks like an ISO 4217 currency code, and
+// ErrUnsupported unless it has two decimal places, as amounts are kept in
+// hundredths (see types.MinorUnits). Exchange rates may be of any currency.
+func Check(c string) error {
+	if !Valid(c) {
+		return ErrCurrency
+	}
+	if unsupported[c] {
+		return ErrUnsupported
+	}
+	return nil
+}
+
+type Service struct {
+	repo repository.Repository
+}
+
+func NewService(repo repository.Repository) *Service {
+	return &Service{repo: repo}
+}
+
+// ParseRates reads exchange rates from a CSV with the header
+// "date,from,to,rate", where dates are formatted as YYYY-MM-DD.
+func ParseRates(r io.Reader) (rates []models.ExchangeRate, err error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = 4
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrRatesCSV, err)
+	}
+	for i, name := range []string{"date", "from", "to", "rate"} {
+		if strings.ToLower(header[i]) != name {
+			return nil, fmt.Errorf("%w: unexpected header %q", ErrRatesCSV, header[i])
+		}
+	}
+
+	for {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrRatesCSV, err)
+		}
+		line, _ := cr.FieldPos(0)
+
+		rate := models.ExchangeRate{
+			Date: record[0],
+			From: strings.ToUpper(record[1]),
+			To:   strings.ToUpper(record[2]),
+		}
+		if _, err := time.Parse(time.DateOnly, rate.Date); err != nil {
+			return nil, fmt.Errorf("%w: line %d: invalid date %q", ErrRatesCSV, line, rate.Date)
+		}
+		if !Valid(rate.From) || !Valid(rate.To) {
+			return nil, fmt.Errorf("%w: line %d: %w", ErrRatesCSV, line, ErrCurrency)
+		}
+		rate.Rate, err = strconv.ParseFloat(record[3], 64)
+		if err != nil || rate.Rate <= 0 {
+			return nil, fmt.Errorf("%w: line %d: invalid rate %q", ErrRatesCSV, line, record[3])
+		}
+		rates = append(rates, rate)
+	}
+	return
+}
+
+// ImportRates stores the exchange rates read from a CSV, replacing any
+// existing rates for the same currencies and date.
+func (s *Service) ImportRates(r io.Reader) (int, error) {
+	rates, err := ParseRates(r)
+	if err != nil {
+		return 0, err
+	}
+	return len(rates), s.repo.ImportExchangeRates(rates)
+}
@@ -0,0 +1,121 @@
+package importer
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"finawise.app/server/models/types"
+	"finawise.app/server/services/currency"
+)
+
+var ErrCSV = fmt.Errorf("invalid csv")
+
+// CSVMapping names the columns of a CSV to read transactions from, as
+// given in its header. Category and Currency are optional.
+type CSVMapping struct {
+	Date        string
+	DateFormat  string // e.g. "DD/MM/YYYY", defaults to "YYYY-MM-DD"
+	Amount      string
+	ExpenseSign string // sign of the amounts of expenses, "-" (default) or "+"
+	Title       string
+	Category    string
+	Currency    string
+}
+
+// ParseCSV reads rows from a CSV whose first record is a header. Rows that
+// cannot be read are returned with an error, instead of failing the rest.
+func ParseCSV(r io.Reader, m CSVMapping) (rows []Row, err error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrCSV, err)
+	}
+	columns := make(map[string]int)
+	for i, name := range header {
+		columns[strings.TrimSpace(name)] = i
+	}
+	index := func(name string, required bool) (int, error) {
+		if name == "" && !required {
+			return -1, nil
+		}
+		i, ok := columns[name]
+		if !ok {
+			return -1, fmt.Errorf("%w: missing column %q", ErrCSV, name)
+		}
+		return i, nil
+	}
+	var date, amount, title, category, cur int
+	for _, c := range []struct {
+		i        *int
+		name     string
+		required bool
+	}{
+		{&date, m.Date, true},
+		{&amount, m.Amount, true},
+		{&title, m.Title, true},
+		{&category, m.Category, false},
+		{&cur, m.Currency, false},
+	} {
+		if *c.i, err = index(c.name, c.required); err != nil {
+			return
+		}
+	}
+
+	format := m.DateFormat
+	if format == "" {
+		format = "YYYY-MM-DD"
+	}
+	sign := types.Money(1)
+	if m.ExpenseSign == "+" {
+		sign = -1
+	}
+
+	field := func(record []string, i int) string {
+		if i < 0 || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	rows = []Row{}
+	for {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		var pe *csv.ParseError
+		if errors.As(err, &pe) {
+			// the reader resumes at the next record
+			rows = append(rows, Row{Line: pe.StartLine, Err: fmt.Errorf("%w: %w", ErrCSV, pe.Err)})
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrCSV, err)
+		}
+		line, _ := cr.FieldPos(0)
+		row := Row{Line: line, Category: field(record, category)}
+		row.Title = field(record, title)
+		row.Currency = strings.ToUpper(field(record, cur))
+
+		row.Timestamp, err = parseDate(format, field(record, date))
+		if err != nil {
+			row.Err = err
+		}
+		a, err := parseAmount(field(record, amount))
+		if err != nil && row.Err == nil {
+			row.Err = err
+		}
+		row.Amount = sign * a
+		if row.Currency != "" && row.Err == nil {
+			row.Err = currency.Check(row.Currency)
+		}
+		rows = append(rows, row)
+	}
+	return
+}
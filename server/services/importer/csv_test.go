@@ -0,0 +1,97 @@
+package importer
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"finawise.app/server/models/types"
+)
+
+func TestParseCSV(t *testing.T) {
+	in := "Date,Amount,Payee,Category,Currency\n" +
+		"05/01/2024,-12.50,Coffee,Food,hkd\n" +
+		"06/01/2024,\"1,000.00\",Salary,,\n" +
+		"07/01/2024,-3.00,\"bad \"quote\",Food,\n" +
+		"08/01/2024,-4.00\n" +
+		"09/01/2024,-5.00,\"two\nlines\",Food,USD\n" +
+		"32/01/2024,-6.00,Late,Food,\n" +
+		"10/01/2024,abc,Typo,Food,\n" +
+		"11/01/2024,-7.00,Cash,Food,dollars\n" +
+		"12/01/2024,-800,Sushi,Food,JPY\n"
+	m := CSVMapping{
+		Date:       "Date",
+		DateFormat: "DD/MM/YYYY",
+		Amount:     "Amount",
+		Title:      "Payee",
+		Category:   "Category",
+		Currency:   "Currency",
+	}
+	rows, err := ParseCSV(strings.NewReader(in), m)
+	if err != nil {
+		t.Fatalf("ParseCSV error = %v", err)
+	}
+	want := []struct {
+		line     int
+		title    string
+		amount   types.Money
+		category string
+		currency string
+		err      bool
+	}{
+		{2, "Coffee", -1250, "Food", "HKD", false},
+		{3, "Salary", 100000, "", "", false},
+		{4, "", 0, "", "", true},
+		{5, "", -400, "", "", false}, // missing fields are empty, and fail on import
+		{6, "two\nlines", -500, "Food", "USD", false},
+		{8, "Late", -600, "Food", "", true},
+		{9, "Typo", 0, "Food", "", true},
+		{10, "Cash", -700, "Food", "DOLLARS", true},
+		{11, "Sushi", -80000, "Food", "JPY", true}, // not in hundredths
+	}
+	if len(rows) != len(want) {
+		t.Fatalf("ParseCSV returned %d rows, want %d", len(rows), len(want))
+	}
+	for i, w := range want {
+		r := rows[i]
+		if r.Line != w.line || (r.Err != nil) != w.err {
+			t.Errorf("row %d = line %d error %v, want line %d error %v", i, r.Line, r.Err, w.line, w.err)
+			continue
+		}
+		if w.err {
+			continue
+		}
+		if r.Title != w.title || r.Amount != w.amount || r.Category != w.category || r.Currency != w.currency {
+			t.Errorf("row %d = %q %s %q %q, want %q %s %q %q", i,
+				r.Title, r.Amount, r.Category, r.Currency, w.title, w.amount, w.category, w.currency)
+		}
+	}
+}
+
+func TestParseCSVExpenseSign(t *testing.T) {
+	in := "date,amount,title\n2024-01-05,12.50,Coffee\n2024-01-06,-100,Refund\n"
+	rows, err := ParseCSV(strings.NewReader(in), CSVMapping{Date: "date", Amount: "amount", Title: "title", ExpenseSign: "+"})
+	if err != nil {
+		t.Fatalf("ParseCSV error = %v", err)
+	}
+	if len(rows) != 2 || rows[0].Amount != -1250 || rows[1].Amount != 10000 {
+		t.Errorf("ParseCSV with expenses positive = %+v", rows)
+	}
+}
+
+func TestParseCSVHeader(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		m    CSVMapping
+	}{
+		{"empty", "", CSVMapping{Date: "date", Amount: "amount", Title: "title"}},
+		{"missing column", "date,amount\n", CSVMapping{Date: "date", Amount: "amount", Title: "title"}},
+		{"missing optional column", "date,amount,title\n", CSVMapping{Date: "date", Amount: "amount", Title: "title", Category: "category"}},
+	}
+	for _, tt := range tests {
+		if _, err := ParseCSV(strings.NewReader(tt.in), tt.m); !errors.Is(err, ErrCSV) {
+			t.Errorf("%s: ParseCSV error = %v, want %v", tt.name, err, ErrCSV)
+		}
+	}
+}
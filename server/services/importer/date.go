@@ -0,0 +1,55 @@
+package importer
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"finawise.app/server/models/types"
+)
+
+// layout converts a date format like "DD/MM/YYYY HH:mm" into a time layout.
+var layout = strings.NewReplacer(
+	"YYYY", "2006",
+	"YY", "06",
+	"MM", "01",
+	"DD", "02",
+	"HH", "15",
+	"mm", "04",
+	"ss", "05",
+)
+
+var digit = regexp.MustCompile(`(^|\D)(\d)(\D|$)`)
+
+// parseDate parses s in UTC according to a date format like "DD/MM/YYYY".
+// Single digits between separators are taken as zero-padded, as in "1/5/2024".
+func parseDate(format, s string) (types.Timestamp, error) {
+	padded := strings.TrimSpace(s)
+	// twice, as adjacent matches overlap on their separator
+	padded = digit.ReplaceAllString(digit.ReplaceAllString(padded, "${1}0$2$3"), "${1}0$2$3")
+	t, err := time.ParseInLocation(layout.Replace(format), padded, time.UTC)
+	if err != nil {
+		return types.Timestamp{}, fmt.Errorf("invalid date %q", s)
+	}
+	return types.Timestamp{Time: t}, nil
+}
+
+// parseAmount parses a signed amount, tolerating thousands separators and
+// currency symbols around it.
+func parseAmount(s string) (types.Money, error) {
+	m, err := types.ParseMoney(strings.Trim(strings.ReplaceAll(s, ",", ""), "$€£¥ "))
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q", s)
+	}
+	return m, nil
+}
+
+// ofxDecimal matches an amount with a single comma followed by one or two
+// digits, which OFX allows as the decimal mark.
+var ofxDecimal = regexp.MustCompile(`^([^,.]*),(\d{1,2})$`)
+
+// parseOFXAmount parses an OFX amount, whose decimal mark may be a comma.
+func parseOFXAmount(s string) (types.Money, error) {
+	return parseAmount(ofxDecimal.ReplaceAllString(strings.TrimSpace(s), "$1.$2"))
+}
@@ -0,0 +1,87 @@
+package importer
+
+import (
+	"testing"
+	"time"
+
+	"finawise.app/server/models/types"
+)
+
+func TestParseDate(t *testing.T) {
+	tests := []struct {
+		format string
+		in     string
+		want   time.Time
+		err    bool
+	}{
+		{"YYYY-MM-DD", "2024-01-05", time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC), false},
+		{"YYYY-MM-DD", " 2024-1-5 ", time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC), false},
+		{"DD/MM/YYYY", "1/5/2024", time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), false},
+		{"MM/DD/YY", "1/5/24", time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC), false},
+		{"DD.MM.YYYY HH:mm", "5.1.2024 9:07", time.Date(2024, 1, 5, 9, 7, 0, 0, time.UTC), false},
+		{"YYYY-MM-DD HH:mm:ss", "2024-01-05 23:59:59", time.Date(2024, 1, 5, 23, 59, 59, 0, time.UTC), false},
+		{"YYYY-MM-DD", "05/01/2024", time.Time{}, true},
+		{"YYYY-MM-DD", "2024-02-30", time.Time{}, true},
+		{"YYYY-MM-DD", "", time.Time{}, true},
+	}
+	for _, tt := range tests {
+		got, err := parseDate(tt.format, tt.in)
+		if (err != nil) != tt.err {
+			t.Errorf("parseDate(%q, %q) error = %v, want error %v", tt.format, tt.in, err, tt.err)
+			continue
+		}
+		if !tt.err && !got.Equal(tt.want) {
+			t.Errorf("parseDate(%q, %q) = %s, want %s", tt.format, tt.in, got.UTC(), tt.want)
+		}
+	}
+}
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		in   string
+		want types.Money
+		err  bool
+	}{
+		{"-12.50", -1250, false},
+		{"1,234.56", 123456, false},
+		{"$1,000", 100000, false},
+		{"€-5.00", -500, false},
+		{"12.345", 0, true},
+		{"", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := parseAmount(tt.in)
+		if (err != nil) != tt.err {
+			t.Errorf("parseAmount(%q) error = %v, want error %v", tt.in, err, tt.err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseAmount(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseOFXAmount(t *testing.T) {
+	tests := []struct {
+		in   string
+		want types.Money
+		err  bool
+	}{
+		{"-12,34", -1234, false},
+		{"5,5", 550, false},
+		{"-12.34", -1234, false},
+		{"1,234.56", 123456, false},
+		{"1,234", 123400, false},
+		{"", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := parseOFXAmount(tt.in)
+		if (err != nil) != tt.err {
+			t.Errorf("parseOFXAmount(%q) error = %v, want error %v", tt.in, err, tt.err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseOFXAmount(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
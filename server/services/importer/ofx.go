@@ -0,0 +1,114 @@
+package importer
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"finawise.app/server/models/types"
+	"finawise.app/server/services/currency"
+)
+
+var ErrOFX = fmt.Errorf("invalid ofx")
+
+// element matches an OFX tag with the value that follows it, which works for
+// both OFX 1.x (SGML, where closing tags are optional) and 2.x (XML).
+var element = regexp.MustCompile(`<(/?)([A-Za-z0-9.]+)>([^<]*)`)
+
+// ParseOFX reads the transactions of the bank and credit card statements of
+// an OFX file, keeping the FITID of each as its external ID.
+func ParseOFX(r io.Reader) (rows []Row, err error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	data := string(b)
+	matches := element.FindAllStringSubmatchIndex(data, -1)
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("%w: no elements", ErrOFX)
+	}
+
+	rows = []Row{}
+	var cur string // CURDEF of the statement being read
+	var row *Row
+	var memo string
+	for _, m := range matches {
+		closing := m[3] > m[2]
+		tag := strings.ToUpper(data[m[4]:m[5]])
+		// values escape markup as entities, like "&amp;", in either version
+		value := html.UnescapeString(strings.TrimSpace(data[m[6]:m[7]]))
+
+		switch {
+		case tag == "STMTTRN" && !closing:
+			row, memo = &Row{Line: strings.Count(data[:m[0]], "\n") + 1}, ""
+			row.Currency = cur
+		case tag == "STMTTRN" && closing:
+			if row == nil {
+				return nil, fmt.Errorf("%w: unexpected </STMTTRN>", ErrOFX)
+			}
+			if row.ExternalID == nil && row.Err == nil {
+				row.Err = fmt.Errorf("missing FITID")
+			}
+			if row.Title == "" {
+				row.Title = memo
+			}
+			rows = append(rows, *row)
+			row = nil
+		case closing:
+		case tag == "CURDEF":
+			cur = strings.ToUpper(value)
+			if err := currency.Check(cur); err != nil {
+				return nil, fmt.Errorf("%w: %w", ErrOFX, err)
+			}
+		case row == nil:
+		case tag == "DTPOSTED":
+			var e error
+			if row.Timestamp, e = parseOFXDate(value); e != nil && row.Err == nil {
+				row.Err = e
+			}
+		case tag == "TRNAMT":
+			var e error
+			if row.Amount, e = parseOFXAmount(value); e != nil && row.Err == nil {
+				row.Err = e
+			}
+		case tag == "FITID":
+			row.ExternalID = &value
+		case tag == "NAME":
+			row.Title = value
+		case tag == "MEMO":
+			memo = value
+		}
+	}
+	if row != nil {
+		return nil, fmt.Errorf("%w: unterminated <STMTTRN>", ErrOFX)
+	}
+	return rows, nil
+}
+
+// parseOFXDate parses a datetime like "20240105120000.000[-5:EST]", where
+// everything after the date is optional and the offset defaults to UTC.
+func parseOFXDate(s string) (types.Timestamp, error) {
+	value, tz, _ := strings.Cut(s, "[")
+	value, _, _ = strings.Cut(value, ".")
+	if len(value) < 8 {
+		return types.Timestamp{}, fmt.Errorf("invalid date %q", s)
+	}
+	value += strings.Repeat("0", max(0, 14-len(value)))
+	loc := time.UTC
+	if tz != "" {
+		offset, _, _ := strings.Cut(strings.TrimSuffix(tz, "]"), ":")
+		var hours float64
+		if _, err := fmt.Sscan(offset, &hours); err != nil {
+			return types.Timestamp{}, fmt.Errorf("invalid date %q", s)
+		}
+		loc = time.FixedZone("", int(hours*3600))
+	}
+	t, err := time.ParseInLocation("20060102150405", value[:14], loc)
+	if err != nil {
+		return types.Timestamp{}, fmt.Errorf("invalid date %q", s)
+	}
+	return types.Timestamp{Time: t.UTC()}, nil
+}
@@ -0,0 +1,161 @@
+package importer
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"finawise.app/server/models/types"
+)
+
+const ofxSGML = `OFXHEADER:100
+DATA:OFXSGML
+VERSION:102
+
+<OFX>
+<BANKMSGSRSV1><STMTTRNRS><STMTRS>
+<CURDEF>usd
+<BANKTRANLIST>
+<STMTTRN>
+<TRNTYPE>DEBIT
+<DTPOSTED>20240105120000.000[-5:EST]
+<TRNAMT>-12.50
+<FITID>1001
+<NAME>Coffee &amp; Co
+</STMTTRN>
+<STMTTRN>
+<TRNTYPE>CREDIT
+<DTPOSTED>20240106
+<TRNAMT>1,000.00
+<FITID>1002
+<MEMO>Salary
+</STMTTRN>
+</BANKTRANLIST>
+</STMTRS></STMTTRNRS></BANKMSGSRSV1>
+</OFX>
+`
+
+const ofxXML = `<?xml version="1.0" encoding="UTF-8"?>
+<?OFX OFXHEADER="200" VERSION="220"?>
+<OFX>
+  <CREDITCARDMSGSRSV1><CCSTMTTRNRS><CCSTMTRS>
+    <CURDEF>EUR</CURDEF>
+    <BANKTRANLIST>
+      <STMTTRN>
+        <DTPOSTED>20240229235959[+1:CET]</DTPOSTED>
+        <TRNAMT>-3.99</TRNAMT>
+        <FITID>A-1</FITID>
+        <NAME>Bakery</NAME>
+      </STMTTRN>
+      <STMTTRN>
+        <DTPOSTED>2024</DTPOSTED>
+        <TRNAMT>-1.00</TRNAMT>
+        <NAME>No FITID</NAME>
+      </STMTTRN>
+    </BANKTRANLIST>
+  </CCSTMTRS></CCSTMTTRNRS></CREDITCARDMSGSRSV1>
+</OFX>
+`
+
+func TestParseOFX(t *testing.T) {
+	type want struct {
+		line     int
+		title    string
+		amount   types.Money
+		currency string
+		fitid    string
+		time     time.Time
+		err      bool
+	}
+	tests := []struct {
+		name string
+		in   string
+		rows []want
+	}{
+		{"sgml", ofxSGML, []want{
+			{9, "Coffee & Co", -1250, "USD", "1001", time.Date(2024, 1, 5, 17, 0, 0, 0, time.UTC), false},
+			{16, "Salary", 100000, "USD", "1002", time.Date(2024, 1, 6, 0, 0, 0, 0, time.UTC), false},
+		}},
+		{"xml", ofxXML, []want{
+			{7, "Bakery", -399, "EUR", "A-1", time.Date(2024, 2, 29, 22, 59, 59, 0, time.UTC), false},
+			{13, "No FITID", -100, "EUR", "", time.Time{}, true},
+		}},
+	}
+	for _, tt := range tests {
+		rows, err := ParseOFX(strings.NewReader(tt.in))
+		if err != nil {
+			t.Errorf("%s: ParseOFX error = %v", tt.name, err)
+			continue
+		}
+		if len(rows) != len(tt.rows) {
+			t.Errorf("%s: ParseOFX returned %d rows, want %d", tt.name, len(rows), len(tt.rows))
+			continue
+		}
+		for i, w := range tt.rows {
+			r := rows[i]
+			if (r.Err != nil) != w.err {
+				t.Errorf("%s: row %d error = %v, want error %v", tt.name, i, r.Err, w.err)
+			}
+			if w.err {
+				continue
+			}
+			if r.Line != w.line || r.Title != w.title || r.Amount != w.amount || r.Currency != w.currency {
+				t.Errorf("%s: row %d = line %d %q %s %s, want line %d %q %s %s", tt.name, i,
+					r.Line, r.Title, r.Amount, r.Currency, w.line, w.title, w.amount, w.currency)
+			}
+			if r.ExternalID == nil || *r.ExternalID != w.fitid {
+				t.Errorf("%s: row %d FITID = %v, want %q", tt.name, i, r.ExternalID, w.fitid)
+			}
+			if !r.Timestamp.Equal(w.time) {
+				t.Errorf("%s: row %d time = %s, want %s", tt.name, i, r.Timestamp.UTC(), w.time)
+			}
+		}
+	}
+}
+
+func TestParseOFXInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+	}{
+		{"no elements", "OFXHEADER:100\n"},
+		{"unterminated", "<OFX><STMTTRN><FITID>1<TRNAMT>1"},
+		{"unexpected close", "<OFX></STMTTRN></OFX>"},
+		{"bad currency", "<OFX><CURDEF>dollars<STMTTRN></STMTTRN></OFX>"},
+	}
+	for _, tt := range tests {
+		if _, err := ParseOFX(strings.NewReader(tt.in)); !errors.Is(err, ErrOFX) {
+			t.Errorf("%s: ParseOFX error = %v, want %v", tt.name, err, ErrOFX)
+		}
+	}
+}
+
+func TestParseOFXDate(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Time
+		err  bool
+	}{
+		{"20240105", time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC), false},
+		{"202401051230", time.Date(2024, 1, 5, 12, 30, 0, 0, time.UTC), false},
+		{"20240105123045", time.Date(2024, 1, 5, 12, 30, 45, 0, time.UTC), false},
+		{"20240105123045.123", time.Date(2024, 1, 5, 12, 30, 45, 0, time.UTC), false},
+		{"20240105123045.123[-5:EST]", time.Date(2024, 1, 5, 17, 30, 45, 0, time.UTC), false},
+		{"20240105000000[+8]", time.Date(2024, 1, 4, 16, 0, 0, 0, time.UTC), false},
+		{"20240105000000[+5.5:IST]", time.Date(2024, 1, 4, 18, 30, 0, 0, time.UTC), false},
+		{"2024010", time.Time{}, true},
+		{"20241305", time.Time{}, true},
+		{"20240105[EST]", time.Time{}, true},
+	}
+	for _, tt := range tests {
+		got, err := parseOFXDate(tt.in)
+		if (err != nil) != tt.err {
+			t.Errorf("parseOFXDate(%q) error = %v, want error %v", tt.in, err, tt.err)
+			continue
+		}
+		if !tt.err && !got.Equal(tt.want) {
+			t.Errorf("parseOFXDate(%q) = %s, want %s", tt.in, got.UTC(), tt.want)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package importer
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+var ErrQIF = fmt.Errorf("invalid qif")
+
+// ParseQIF reads the transactions of a QIF file, with dates in the given
+// format, or else "MM/DD/YYYY" or "MM/DD/YY" as written by Quicken.
+// Transfers to other accounts, whose category is written in brackets, are
+// read without a category.
+func ParseQIF(r io.Reader, format string) (rows []Row, err error) {
+	formats := []string{format}
+	if format == "" {
+		formats = []string{"MM/DD/YYYY", "MM/DD/YY"}
+	}
+
+	sc := bufio.NewScanner(r)
+	rows = []Row{}
+	var row *Row
+	var memo string
+	var typ string // type of the section being read
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimRight(sc.Text(), "\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "!") {
+			// other headers, like "!Account", start sections of their own
+			typ = ""
+			if strings.HasPrefix(strings.ToLower(line), "!type:") {
+				typ = strings.ToLower(strings.TrimSpace(line[len("!type:"):]))
+			}
+			continue
+		}
+		switch typ {
+		case "bank", "cash", "ccard", "oth a", "oth l":
+		default:
+			continue // not a list of transactions
+		}
+
+		code, value := line[0], strings.TrimSpace(line[1:])
+		if row == nil {
+			row, memo = &Row{Line: n}, ""
+		}
+		switch code {
+		case 'D':
+			// dates are written like "1/ 5/98" or "1/5'2024" too
+			value = strings.ReplaceAll(strings.ReplaceAll(value, " ", ""), "'", "/")
+			var e error
+			for _, format := range formats {
+				if row.Timestamp, e = parseDate(format, value); e == nil {
+					break
+				}
+			}
+			if e != nil && row.Err == nil {
+				row.Err = e
+			}
+		case 'T', 'U':
+			var e error
+			if row.Amount, e = parseAmount(value); e != nil && row.Err == nil {
+				row.Err = e
+			}
+		case 'P':
+			row.Title = value
+		case 'M':
+			memo = value
+		case 'L':
+			if !strings.HasPrefix(value, "[") {
+				row.Category = value
+			}
+		case '^':
+			if row.Title == "" {
+				row.Title = memo
+			}
+			if row.Timestamp.IsZero() && row.Err == nil {
+				row.Err = fmt.Errorf("missing date")
+			}
+			rows = append(rows, *row)
+			row = nil
+		}
+	}
+	if err = sc.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrQIF, err)
+	}
+	if row != nil {
+		return nil, fmt.Errorf("%w: unterminated transaction at line %d", ErrQIF, row.Line)
+	}
+	return rows, nil
+}
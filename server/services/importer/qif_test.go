@@ -0,0 +1,124 @@
+package importer
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"finawise.app/server/models/types"
+)
+
+func TestParseQIFDates(t *testing.T) {
+	date := func(y int, m time.Month, d int) time.Time {
+		return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	}
+	tests := []struct {
+		format string
+		in     string
+		want   time.Time
+		err    bool
+	}{
+		{"", "01/05/2024", date(2024, 1, 5), false},
+		{"", "1/5/2024", date(2024, 1, 5), false},
+		{"", "1/5/98", date(1998, 1, 5), false},
+		{"", "1/ 5/98", date(1998, 1, 5), false},
+		{"", "12/31/24", date(2024, 12, 31), false},
+		{"", "1/5'2024", date(2024, 1, 5), false},
+		{"", "1/5' 4", date(2004, 1, 5), false},
+		{"", "2/29/2024", date(2024, 2, 29), false},
+		{"DD/MM/YYYY", "5/1/2024", date(2024, 1, 5), false},
+		{"YYYY-MM-DD", "2024-01-05", date(2024, 1, 5), false},
+		{"", "13/01/2024", time.Time{}, true},
+		{"", "2/29/2023", time.Time{}, true},
+		{"DD/MM/YYYY", "5/1/24", time.Time{}, true},
+	}
+	for _, tt := range tests {
+		in := "!Type:Bank\nD" + tt.in + "\nT-1.00\nPPayee\n^\n"
+		rows, err := ParseQIF(strings.NewReader(in), tt.format)
+		if err != nil || len(rows) != 1 {
+			t.Errorf("ParseQIF(%q, %q) = %d rows, %v", tt.in, tt.format, len(rows), err)
+			continue
+		}
+		r := rows[0]
+		if (r.Err != nil) != tt.err {
+			t.Errorf("ParseQIF(%q, %q) row error = %v, want error %v", tt.in, tt.format, r.Err, tt.err)
+			continue
+		}
+		if !tt.err && !r.Timestamp.Equal(tt.want) {
+			t.Errorf("ParseQIF(%q, %q) date = %s, want %s", tt.in, tt.format, r.Timestamp.UTC(), tt.want)
+		}
+	}
+}
+
+func TestParseQIF(t *testing.T) {
+	in := "!Account\nNChecking\n^\n" +
+		"!Type:Bank\r\n" +
+		"D1/5/2024\r\nU-1,234.50\r\nT-1,234.50\r\nPRent\r\nLHousing\r\n^\r\n" +
+		"D1/6/2024\nT100.00\nMSavings\nL[Savings]\n^\n" +
+		"\n" +
+		"T-5.00\nPNo date\n^\n" +
+		"!Type:Cat\nNFood\nE\n^\n"
+	rows, err := ParseQIF(strings.NewReader(in), "")
+	if err != nil {
+		t.Fatalf("ParseQIF error = %v", err)
+	}
+	want := []struct {
+		line     int
+		title    string
+		amount   types.Money
+		category string
+		err      bool
+	}{
+		{5, "Rent", -123450, "Housing", false},
+		{11, "Savings", 10000, "", false},
+		{17, "No date", -500, "", true},
+	}
+	if len(rows) != len(want) {
+		t.Fatalf("ParseQIF returned %d rows, want %d", len(rows), len(want))
+	}
+	for i, w := range want {
+		r := rows[i]
+		if r.Line != w.line || r.Title != w.title || r.Amount != w.amount || r.Category != w.category || (r.Err != nil) != w.err {
+			t.Errorf("row %d = line %d %q %s %q %v, want line %d %q %s %q error %v", i,
+				r.Line, r.Title, r.Amount, r.Category, r.Err, w.line, w.title, w.amount, w.category, w.err)
+		}
+	}
+
+	if _, err := ParseQIF(strings.NewReader("!Type:Bank\nD1/5/2024\nT1\n"), ""); !errors.Is(err, ErrQIF) {
+		t.Errorf("ParseQIF of unterminated transaction error = %v, want %v", err, ErrQIF)
+	}
+}
+
+func TestParseQIFAccounts(t *testing.T) {
+	in := "!Option:AutoSwitch\n" +
+		"!Account\nNChecking\nTBank\n^\nNVisa\nTCCard\n^\n" +
+		"!Clear:AutoSwitch\n" +
+		"!Account\nNChecking\nTBank\n^\n" +
+		"!Type:Bank\nD1/5/2024\nT-10.00\nPGroceries\n^\n" +
+		"!Account\nNVisa\nTCCard\n^\n" +
+		"!Type:CCard\nD1/6/2024\nT-20.00\nPFuel\n^\n" +
+		"!Account\nNCash\nTCash\n^\n"
+	rows, err := ParseQIF(strings.NewReader(in), "")
+	if err != nil {
+		t.Fatalf("ParseQIF error = %v", err)
+	}
+	want := []struct {
+		line   int
+		title  string
+		amount types.Money
+	}{
+		{15, "Groceries", -1000},
+		{24, "Fuel", -2000},
+	}
+	if len(rows) != len(want) {
+		t.Fatalf("ParseQIF returned %d rows, want %d", len(rows), len(want))
+	}
+	for i, w := range want {
+		r := rows[i]
+		if r.Line != w.line || r.Title != w.title || r.Amount != w.amount || r.Err != nil {
+			t.Errorf("row %d = line %d %q %s %v, want line %d %q %s", i,
+				r.Line, r.Title, r.Amount, r.Err, w.line, w.title, w.amount)
+		}
+	}
+}
@@ -0,0 +1,220 @@
+package importer
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/go-playground/validator/v10"
+
+	"finawise.app/server/models"
+	"finawise.app/server/models/types"
+	"finawise.app/server/repository"
+	"finawise.app/server/services/account"
+)
+
+var (
+	ErrCategory     = fmt.Errorf("unknown category")
+	ErrCategoryName = fmt.Errorf("invalid category name")
+	ErrTitle        = fmt.Errorf("missing title")
+	ErrAmount       = fmt.Errorf("zero amount")
+	ErrWallet       = fmt.Errorf("unknown wallet")
+)
+
+// categoryName validates the names of categories created by an import, the
+// same as those created by the createCategory mutation.
+const categoryName = "required,max=20,printascii"
+
+var validate = validator.New(validator.WithRequiredStructEnabled())
+
+// Row is a transaction read from a statement. Until imported, its amount is
+// negative for expenses, its currency is empty for the base currency of the
+// group, and its category is only known by name, if at all.
+type Row struct {
+	models.Transaction
+	Line     int
+	Category string // category of the payee, or default of the type if empty
+	Err      error  // set if the row could not be read
+}
+
+type Options struct {
+	DefaultIncome    *types.ID
+	DefaultExpense   *types.ID
+	Wallet           *types.ID // default wallet of the group if nil
+	CreateCategories bool      // create categories that do not exist yet by name
+	DryRun           bool
+}
+
+type Status string
+
+const (
+	StatusImported  Status = "imported"
+	StatusDuplicate Status = "duplicate"
+	StatusFailed    Status = "failed"
+)
+
+type Result struct {
+	Line        int                 `json:"line"`
+	Status      Status              `json:"status"`
+	Error       string              `json:"error,omitempty"`
+	Transaction *models.Transaction `json:"transaction,omitempty"`
+}
+
+// Report describes the outcome of an import. Nothing is imported if any row
+// failed, or for a dry run, but the report is the same as if it was.
+type Report struct {
+	DryRun     bool              `json:"dryRun"`
+	Imported   int               `json:"imported"`
+	Duplicates int               `json:"duplicates"`
+	Failed     int               `json:"failed"`
+	Categories []models.Category `json:"categories"` // categories created
+	Rows       []Result          `json:"rows"`
+}
+
+type Service struct {
+	repo repository.Repository
+}
+
+func NewService(repo repository.Repository) *Service {
+	return &Service{repo: repo}
+}
+
+// Import creates the transactions of rows for the account of the session,
+// skipping those with the same date, amount and title as an existing one.
+func (s *Service) Import(session account.Session, rows []Row, opts Options) (rep Report, err error) {
+	g, err := s.repo.GetGroup(session.GroupID)
+	if err != nil {
+		return
+	}
+	cs, err := s.repo.GetCategories(session.GroupID, nil)
+	if err != nil {
+		return
+	}
+	wid := types.ZeroID
+	if opts.Wallet != nil {
+		wid = *opts.Wallet
+	}
+	w, err := s.repo.GetWallet(session.GroupID, wid)
+	if err == repository.ErrNoRows {
+		return rep, ErrWallet
+	}
+	if err != nil {
+		return
+	}
+	type key struct {
+		Type models.CategoryType
+		Name string
+	}
+	byName := make(map[key]models.Category)
+	byID := make(map[types.ID]models.Category)
+	for _, c := range cs {
+		byName[key{c.Type, strings.ToLower(c.Name)}] = c
+		byID[c.ID] = c
+	}
+	defaults := map[models.CategoryType]*types.ID{
+		models.CategoryTypeIncome:  opts.DefaultIncome,
+		models.CategoryTypeExpense: opts.DefaultExpense,
+	}
+
+	rep.Categories = []models.Category{}
+	rep.Rows = make([]Result, len(rows))
+	var txns []models.Transaction
+	var results []int // index of the result of each transaction
+	for i, row := range rows {
+		rep.Rows[i] = Result{Line: row.Line, Status: StatusFailed}
+		if row.Err == nil && strings.TrimSpace(row.Title) == "" {
+			row.Err = ErrTitle
+		}
+		if row.Err == nil && row.Amount == 0 {
+			row.Err = ErrAmount
+		}
+		if row.Err != nil {
+			rep.Rows[i].Error = row.Err.Error()
+			rep.Failed++
+			continue
+		}
+
+		t, amount := models.CategoryTypeIncome, row.Amount
+		if amount < 0 {
+			t, amount = models.CategoryTypeExpense, -amount
+		}
+
+		var c models.Category
+		var ok bool
+		if name := strings.TrimSpace(row.Category); name != "" {
+			c, ok = byName[key{t, strings.ToLower(name)}]
+			if !ok && opts.CreateCategories {
+				if validate.Var(name, categoryName) != nil {
+					rep.Rows[i].Error = ErrCategoryName.Error()
+					rep.Failed++
+					continue
+				}
+				c, ok = models.Category{
+					ID:      types.MakeID(),
+					GroupID: session.GroupID,
+					Name:    name,
+					Type:    t,
+					Emoji:   "📥",
+					Color:   "#808080",
+				}, true
+				byName[key{t, strings.ToLower(name)}] = c
+				rep.Categories = append(rep.Categories, c)
+			}
+		} else if cid, err := s.repo.GetPayeeCategory(session.AccountID, row.Title, t); err == nil {
+			// payees keep the category of their previous transactions
+			c, ok = byID[cid]
+		} else if err != repository.ErrNoRows {
+			return rep, err
+		} else if id := defaults[t]; id != nil {
+			c, ok = byID[*id]
+			ok = ok && c.Type == t
+		}
+		if !ok {
+			rep.Rows[i].Error = fmt.Sprintf("%s for %s", ErrCategory, strings.ToLower(string(t)))
+			rep.Failed++
+			continue
+		}
+
+		txn := models.Transaction{
+			ID:         types.MakeID(),
+			AccountID:  session.AccountID,
+			CategoryID: c.ID,
+			WalletID:   w.ID,
+			Title:      truncate(strings.TrimSpace(row.Title), 30),
+			Amount:     amount,
+			Timestamp:  row.Timestamp,
+			Currency:   g.Currency,
+			ExternalID: row.ExternalID,
+		}
+		if row.Currency != "" {
+			txn.Currency = row.Currency
+		}
+		txns = append(txns, txn)
+		results = append(results, i)
+	}
+
+	rep.DryRun = opts.DryRun || rep.Failed > 0
+	duplicates, err := s.repo.ImportTransactions(session.GroupID, rep.Categories, txns, rep.DryRun)
+	if err != nil {
+		return
+	}
+	for j, i := range results {
+		rep.Rows[i].Transaction = &txns[j]
+		if duplicates[j] {
+			rep.Rows[i].Status = StatusDuplicate
+			rep.Duplicates++
+		} else {
+			rep.Rows[i].Status = StatusImported
+			rep.Imported++
+		}
+	}
+	return
+}
+
+// truncate shortens s to at most n characters.
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n])
+}
@@ -0,0 +1,68 @@
+package pubsub
+
+import (
+	"context"
+	"sync"
+
+	"finawise.app/server/models"
+)
+
+// Buffer is the number of messages kept for a subscriber that is behind.
+// Further messages are dropped until it catches up.
+const Buffer = 16
+
+// Topic delivers messages to the subscribers of the same group.
+type Topic[T any] struct {
+	mu   sync.Mutex
+	subs map[int64]map[chan T]struct{}
+}
+
+func (t *Topic[T]) Publish(gid int64, msg T) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for ch := range t.subs[gid] {
+		select {
+		case ch <- msg:
+		default:
+		}
+	}
+}
+
+// Subscribe returns a channel of the messages published to group gid,
+// which is closed once ctx is done.
+func (t *Topic[T]) Subscribe(ctx context.Context, gid int64) <-chan T {
+	ch := make(chan T, Buffer)
+	t.mu.Lock()
+	if t.subs == nil {
+		t.subs = make(map[int64]map[chan T]struct{})
+	}
+	if t.subs[gid] == nil {
+		t.subs[gid] = make(map[chan T]struct{})
+	}
+	t.subs[gid][ch] = struct{}{}
+	t.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		delete(t.subs[gid], ch)
+		if len(t.subs[gid]) == 0 {
+			delete(t.subs, gid)
+		}
+		close(ch)
+	}()
+	return ch
+}
+
+// Broker publishes the changes of the entities of a group to the devices
+// subscribed to them, within this process.
+type Broker struct {
+	Transactions Topic[models.TransactionChange]
+	Budgets      Topic[models.BudgetChange]
+	Categories   Topic[models.CategoryChange]
+}
+
+func NewBroker() *Broker {
+	return &Broker{}
+}
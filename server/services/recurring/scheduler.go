@@ -0,0 +1,101 @@
+package recurring
+
+import (
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"finawise.app/server/models"
+	"finawise.app/server/models/types"
+	"finawise.app/server/repository"
+	"finawise.app/server/services/alerts"
+	"finawise.app/server/services/pubsub"
+)
+
+// Interval is how often the scheduler looks for due occurrences.
+const Interval = 1 * time.Minute
+
+// Scheduler creates the transactions of recurrences as they become due.
+// Occurrences missed while the server was down are caught up on the next
+// runs, models.MaxDue of each recurrence at a time.
+type Scheduler struct {
+	repo   repository.Repository
+	alerts *alerts.Service
+	broker *pubsub.Broker
+
+	stop chan struct{}
+	done sync.WaitGroup
+}
+
+func NewScheduler(repo repository.Repository, alerts *alerts.Service, broker *pubsub.Broker) *Scheduler {
+	return &Scheduler{repo: repo, alerts: alerts, broker: broker, stop: make(chan struct{})}
+}
+
+func (s *Scheduler) Initialize() error {
+	s.done.Add(1)
+	go func() {
+		defer s.done.Done()
+		// the repository is initialized alongside the scheduler
+		select {
+		case <-s.stop:
+			return
+		case <-s.repo.Ready():
+		}
+		// catch up at once rather than an interval after starting
+		if err := s.Run(time.Now()); err != nil {
+			log.Error().Str("from", "scheduler").Msg(err.Error())
+		}
+		ticker := time.NewTicker(Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-s.stop:
+				return
+			case now := <-ticker.C:
+				if err := s.Run(now); err != nil {
+					log.Error().Str("from", "scheduler").Msg(err.Error())
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+func (s *Scheduler) Terminate() error {
+	close(s.stop)
+	s.done.Wait()
+	return nil
+}
+
+// Run creates the transactions of every occurrence due by now. It is
+// idempotent, as each occurrence is created at most once. Transactions
+// created alert budget thresholds and are published, as if created by the
+// member. A recurrence that fails is logged and left to the next run, so
+// that it does not hold up the others.
+func (s *Scheduler) Run(now time.Time) error {
+	recs, err := s.repo.ListDueRecurrences(types.Timestamp{Time: now})
+	if err != nil {
+		return err
+	}
+	for _, rec := range recs {
+		txns, err := s.repo.CreateOccurrences(rec, rec.Due(now))
+		if err != nil {
+			log.Error().Str("from", "scheduler").Str("recurrence", rec.ID.String()).Msg(err.Error())
+			continue
+		}
+		if len(txns) == 0 {
+			continue
+		}
+		a, err := s.repo.GetAccount(rec.AccountID)
+		if err != nil {
+			log.Error().Str("from", "scheduler").Str("recurrence", rec.ID.String()).Msg(err.Error())
+			continue
+		}
+		for _, txn := range txns {
+			s.alerts.Transaction(a.GroupID, txn, nil)
+			s.broker.Transactions.Publish(a.GroupID, models.TransactionChange{Action: models.ChangeActionCreated, ID: txn.ID, Transaction: &txn})
+		}
+	}
+	return nil
+}
@@ -4,11 +4,49 @@ import (
 	"finawise.app/server/container"
 	"finawise.app/server/repository"
 	"finawise.app/server/services/account"
+	"finawise.app/server/services/alerts"
+	"finawise.app/server/services/currency"
+	"finawise.app/server/services/importer"
+	"finawise.app/server/services/pubsub"
+	"finawise.app/server/services/recurring"
 )
 
-type AccountService = account.Service
+type (
+	AccountService  = account.Service
+	AlertService    = alerts.Service
+	CurrencyService = currency.Service
+	ImportService   = importer.Service
+	Scheduler       = recurring.Scheduler
+	Broker          = pubsub.Broker
+)
 
 func NewAccountService(c *container.Container) *account.Service {
 	repo := container.Use[repository.Repository](c, "repository")
 	return account.NewService(repo)
 }
+
+func NewAlertService(c *container.Container) *alerts.Service {
+	repo := container.Use[repository.Repository](c, "repository")
+	return alerts.NewService(repo)
+}
+
+func NewCurrencyService(c *container.Container) *currency.Service {
+	repo := container.Use[repository.Repository](c, "repository")
+	return currency.NewService(repo)
+}
+
+func NewImportService(c *container.Container) *importer.Service {
+	repo := container.Use[repository.Repository](c, "repository")
+	return importer.NewService(repo)
+}
+
+func NewScheduler(c *container.Container) *recurring.Scheduler {
+	repo := container.Use[repository.Repository](c, "repository")
+	alerts := container.Use[*alerts.Service](c, "service/alerts")
+	broker := container.Use[*pubsub.Broker](c, "service/broker")
+	return recurring.NewScheduler(repo, alerts, broker)
+}
+
+func NewBroker(c *container.Container) *pubsub.Broker {
+	return pubsub.NewBroker()
+}